@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"google-auth-wizard/logger"
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const DEFAULT_USERINFO_URL = "https://openidconnect.googleapis.com/v1/userinfo"
+
+var emailScopes = []string{"email", "https://www.googleapis.com/auth/userinfo.email"}
+
+func GrantsEmail(scopes []string) bool {
+	for _, scope := range scopes {
+		if slices.Contains(emailScopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func AccountEmail(ctx context.Context, userInfoURL string, token *oauth2.Token) string {
+	if token == nil {
+		return ""
+	}
+
+	if idToken, ok := token.Extra("id_token").(string); ok && idToken != "" {
+		email, err := emailFromIDToken(idToken)
+		if err == nil && email != "" {
+			return email
+		}
+		logger.Debug("Unable to read email from id_token: %v", err)
+	}
+
+	if token.AccessToken == "" {
+		return ""
+	}
+
+	if userInfoURL == "" {
+		userInfoURL = DEFAULT_USERINFO_URL
+	}
+
+	email, err := emailFromUserInfo(ctx, userInfoURL, token)
+	if err != nil {
+		logger.Debug("Unable to read email from userinfo: %v", err)
+		return ""
+	}
+	return email
+}
+
+func emailFromIDToken(idToken string) (string, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed id_token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode id_token payload: %w", err)
+	}
+
+	var claims struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("failed to parse id_token claims: %w", err)
+	}
+
+	return claims.Email, nil
+}
+
+func emailFromUserInfo(ctx context.Context, userInfoURL string, token *oauth2.Token) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, userInfoURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create userinfo request: %w", err)
+	}
+	token.SetAuthHeader(req)
+
+	resp, err := httpClientFromContext(ctx).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach userinfo endpoint: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("userinfo returned status %d", resp.StatusCode)
+	}
+
+	var userInfo struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+		return "", fmt.Errorf("failed to parse userinfo response: %w", err)
+	}
+
+	return userInfo.Email, nil
+}
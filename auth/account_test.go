@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func fakeIDToken(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func newFakeUserInfoServer(t *testing.T, email string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fake-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"sub":"123","email":"` + email + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAccountEmail_FromIDToken(t *testing.T) {
+	token := (&oauth2.Token{AccessToken: "fake-access-token"}).WithExtra(map[string]interface{}{
+		"id_token": fakeIDToken(`{"sub":"123","email":"id-token@example.com"}`),
+	})
+
+	userInfo := newFakeUserInfoServer(t, "userinfo@example.com")
+
+	if email := AccountEmail(context.Background(), userInfo.URL, token); email != "id-token@example.com" {
+		t.Errorf("Expected email from id_token, got %q", email)
+	}
+}
+
+func TestAccountEmail_FromUserInfo(t *testing.T) {
+	token := (&oauth2.Token{AccessToken: "fake-access-token"}).WithExtra(map[string]interface{}{
+		"id_token": fakeIDToken(`{"sub":"123"}`),
+	})
+
+	userInfo := newFakeUserInfoServer(t, "userinfo@example.com")
+
+	if email := AccountEmail(context.Background(), userInfo.URL, token); email != "userinfo@example.com" {
+		t.Errorf("Expected email from userinfo, got %q", email)
+	}
+}
+
+func TestAccountEmail_Unavailable(t *testing.T) {
+	userInfo := newFakeUserInfoServer(t, "userinfo@example.com")
+
+	if email := AccountEmail(context.Background(), userInfo.URL, &oauth2.Token{AccessToken: "wrong-token"}); email != "" {
+		t.Errorf("Expected no email when userinfo rejects the token, got %q", email)
+	}
+
+	if email := AccountEmail(context.Background(), userInfo.URL, nil); email != "" {
+		t.Errorf("Expected no email for a nil token, got %q", email)
+	}
+}
+
+func TestAccountEmail_DefaultUserInfoURL(t *testing.T) {
+	userInfo := newFakeUserInfoServer(t, "userinfo@example.com")
+	transport := &recordingTransport{RoundTripper: handlerTransport{handler: userInfo.Config.Handler}}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+
+	if email := AccountEmail(ctx, "", &oauth2.Token{AccessToken: "fake-access-token"}); email != "userinfo@example.com" {
+		t.Errorf("Expected email from the default userinfo endpoint, got %q", email)
+	}
+
+	if len(transport.urls) != 1 || transport.urls[0] != DEFAULT_USERINFO_URL {
+		t.Errorf("Expected a request to %s, got %v", DEFAULT_USERINFO_URL, transport.urls)
+	}
+}
+
+func TestAccountEmail_WithoutEmailScope(t *testing.T) {
+	token := (&oauth2.Token{AccessToken: "fake-access-token"}).WithExtra(map[string]interface{}{
+		"id_token": fakeIDToken(`{"sub":"123"}`),
+	})
+
+	userInfo := newFakeUserInfoServer(t, "")
+
+	if email := AccountEmail(context.Background(), userInfo.URL, token); email != "" {
+		t.Errorf("Expected no email without the email scope, got %q", email)
+	}
+}
+
+func TestGrantsEmail(t *testing.T) {
+	tests := []struct {
+		scopes   []string
+		expected bool
+	}{
+		{[]string{"openid", "email"}, true},
+		{[]string{"https://www.googleapis.com/auth/userinfo.email"}, true},
+		{[]string{"openid"}, false},
+		{[]string{"https://www.googleapis.com/auth/drive.readonly"}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := GrantsEmail(tt.scopes); got != tt.expected {
+			t.Errorf("GrantsEmail(%v) = %v, expected %v", tt.scopes, got, tt.expected)
+		}
+	}
+}
+
+func TestEmailFromIDToken_Malformed(t *testing.T) {
+	if _, err := emailFromIDToken("not-a-jwt"); err == nil {
+		t.Error("Expected error for malformed id_token, got nil")
+	}
+
+	if _, err := emailFromIDToken("a.!!!.c"); err == nil {
+		t.Error("Expected error for undecodable payload, got nil")
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	AUTHORIZED_USER_TYPE = "authorized_user"
+	ADC_FILE_NAME        = "application_default_credentials.json"
+)
+
+type AuthorizedUserCredentials struct {
+	Type           string `json:"type"`
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	QuotaProjectID string `json:"quota_project_id,omitempty"`
+	Account        string `json:"account,omitempty"`
+}
+
+func NewAuthorizedUserCredentials(oauthConfig *oauth2.Config, token *oauth2.Token, quotaProjectID, account string) (*AuthorizedUserCredentials, error) {
+	if token == nil || token.RefreshToken == "" {
+		return nil, fmt.Errorf("%s: Application Default Credentials need a refresh token", MISSING_REFRESH_TOKEN_MSG)
+	}
+
+	if oauthConfig.ClientID == "" || oauthConfig.ClientSecret == "" {
+		return nil, fmt.Errorf("credentials file has no client ID or client secret")
+	}
+
+	return &AuthorizedUserCredentials{
+		Type:           AUTHORIZED_USER_TYPE,
+		ClientID:       oauthConfig.ClientID,
+		ClientSecret:   oauthConfig.ClientSecret,
+		RefreshToken:   token.RefreshToken,
+		QuotaProjectID: quotaProjectID,
+		Account:        account,
+	}, nil
+}
+
+func DefaultADCPath() (string, error) {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, ADC_FILE_NAME), nil
+	}
+
+	if runtime.GOOS == "windows" {
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return "", fmt.Errorf("APPDATA is not set")
+		}
+		return filepath.Join(appData, "gcloud", ADC_FILE_NAME), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to locate home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "gcloud", ADC_FILE_NAME), nil
+}
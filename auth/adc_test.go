@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+func TestNewAuthorizedUserCredentials(t *testing.T) {
+	oauthConfig := &oauth2.Config{ClientID: "client-id", ClientSecret: "client-secret"}
+	token := &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"}
+
+	adc, err := NewAuthorizedUserCredentials(oauthConfig, token, "quota-project", "user@example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := json.Marshal(adc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	expected := map[string]string{
+		"type":             "authorized_user",
+		"client_id":        "client-id",
+		"client_secret":    "client-secret",
+		"refresh_token":    "refresh-token",
+		"quota_project_id": "quota-project",
+		"account":          "user@example.com",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("Expected %s %q, got %q", key, value, fields[key])
+		}
+	}
+
+	if _, err := google.CredentialsFromJSON(context.Background(), data, "https://www.googleapis.com/auth/drive"); err != nil {
+		t.Errorf("Expected the Google client library to accept the exported file, got %v", err)
+	}
+}
+
+func TestNewAuthorizedUserCredentials_MissingValues(t *testing.T) {
+	oauthConfig := &oauth2.Config{ClientID: "client-id", ClientSecret: "client-secret"}
+
+	if _, err := NewAuthorizedUserCredentials(oauthConfig, &oauth2.Token{AccessToken: "access-only"}, "", ""); err == nil {
+		t.Error("Expected error without a refresh token, got nil")
+	}
+
+	if _, err := NewAuthorizedUserCredentials(&oauth2.Config{ClientID: "client-id"}, &oauth2.Token{RefreshToken: "refresh-token"}, "", ""); err == nil {
+		t.Error("Expected error without a client secret, got nil")
+	}
+}
+
+func TestDefaultADCPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLOUDSDK_CONFIG", dir)
+
+	path, err := DefaultADCPath()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if path != filepath.Join(dir, ADC_FILE_NAME) {
+		t.Errorf("Expected CLOUDSDK_CONFIG to be honored, got %s", path)
+	}
+
+	t.Setenv("CLOUDSDK_CONFIG", "")
+	t.Setenv("HOME", dir)
+	if runtime.GOOS == "windows" {
+		t.Setenv("APPDATA", dir)
+	}
+
+	path, err = DefaultADCPath()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if filepath.Base(filepath.Dir(path)) != "gcloud" || filepath.Base(path) != ADC_FILE_NAME {
+		t.Errorf("Expected the gcloud ADC location, got %s", path)
+	}
+}
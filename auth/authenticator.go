@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/logger"
+	"google-auth-wizard/utils"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type Authenticator struct {
+	cfg         *config.Config
+	oauthConfig *oauth2.Config
+	openBrowser func(url string) error
+	httpClient  *http.Client
+	in          io.Reader
+	out         io.Writer
+}
+
+type AuthenticatorOption func(*Authenticator)
+
+func WithBrowserOpener(openBrowser func(url string) error) AuthenticatorOption {
+	return func(a *Authenticator) {
+		a.openBrowser = openBrowser
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) AuthenticatorOption {
+	return func(a *Authenticator) {
+		a.httpClient = httpClient
+	}
+}
+
+func WithInput(in io.Reader) AuthenticatorOption {
+	return func(a *Authenticator) {
+		a.in = in
+	}
+}
+
+func WithOutput(out io.Writer) AuthenticatorOption {
+	return func(a *Authenticator) {
+		a.out = out
+	}
+}
+
+func NewAuthenticator(cfg *config.Config, oauthConfig *oauth2.Config, options ...AuthenticatorOption) *Authenticator {
+	authenticator := &Authenticator{
+		cfg:         cfg,
+		oauthConfig: oauthConfig,
+		openBrowser: utils.OpenBrowser,
+		in:          os.Stdin,
+		out:         os.Stdout,
+	}
+
+	for _, option := range options {
+		option(authenticator)
+	}
+
+	return authenticator
+}
+
+func (a *Authenticator) Token(ctx context.Context) (*oauth2.Token, error) {
+	switch a.cfg.OAuth.Flow {
+	case "", config.FLOW_LOOPBACK:
+		return a.LocalServerToken(ctx)
+	case config.FLOW_DEVICE:
+		return a.DeviceToken(ctx)
+	case config.FLOW_MANUAL:
+		return a.ManualToken(ctx)
+	default:
+		return nil, fmt.Errorf("unknown authorization flow: %q", a.cfg.OAuth.Flow)
+	}
+}
+
+func (a *Authenticator) LocalServerToken(ctx context.Context) (*oauth2.Token, error) {
+	ctx = a.contextWithHTTPClient(ctx)
+
+	state, err := generateState()
+	if err != nil {
+		return nil, fmt.Errorf("error generating state token: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	listeners, redirectHost, err := a.listenLoopback()
+	if err != nil {
+		return nil, err
+	}
+	port := utils.ListenerPort(listeners[0])
+
+	conf := a.flowConfig()
+	conf.RedirectURL = fmt.Sprintf("http://%s%s", net.JoinHostPort(redirectHost, strconv.Itoa(port)), a.cfg.OAuth.CallbackPath)
+
+	logger.Info("Using port %d for OAuth callback", port)
+	logger.Debug("Redirect URL: %s", conf.RedirectURL)
+
+	tokenChan := make(chan *oauth2.Token, 1)
+	errChan := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(a.cfg.OAuth.CallbackPath, createCallbackHandler(ctx, conf, state, verifier, tokenChan, errChan))
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			logger.Debug("Serving OAuth callback on %s", listener.Addr())
+			if err := srv.Serve(listener); err != http.ErrServerClosed {
+				sendError(errChan, fmt.Errorf("server error: %v", err))
+			}
+		}(listener)
+	}
+	defer func() {
+		if err := srv.Shutdown(context.Background()); err != nil {
+			logger.Debug("Error shutting down server: %v", err)
+		}
+	}()
+
+	authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+	logger.Info("Opening browser to: %s", authURL)
+
+	if err := a.openBrowser(authURL); err != nil {
+		logger.Info("Unable to open browser automatically. Please open manually: %s", authURL)
+	}
+
+	select {
+	case token := <-tokenChan:
+		logger.Info("Authorization successful!")
+		return token, nil
+	case err := <-errChan:
+		return nil, fmt.Errorf("authorization error: %w", err)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("authorization cancelled: %w", ctx.Err())
+	case <-time.After(a.cfg.Server.ServerTimeout):
+		return nil, fmt.Errorf("timeout: authorization not received within %v", a.cfg.Server.ServerTimeout)
+	}
+}
+
+func (a *Authenticator) listenLoopback() ([]net.Listener, string, error) {
+	listener, err := utils.ListenLoopback(a.cfg.Server.DefaultPort, a.cfg.Server.MaxPortTries)
+	if err != nil {
+		return nil, "", fmt.Errorf("error listening on loopback interface: %w", err)
+	}
+
+	if !a.cfg.Server.ListenIPv6 {
+		return []net.Listener{listener}, utils.LOOPBACK_IPV4, nil
+	}
+
+	ipv6Listener, err := utils.ListenLoopbackIPv6(utils.ListenerPort(listener))
+	if err != nil {
+		logger.Debug("IPv6 loopback unavailable, using IPv4 only: %v", err)
+		return []net.Listener{listener}, utils.LOOPBACK_IPV4, nil
+	}
+
+	return []net.Listener{listener, ipv6Listener}, "localhost", nil
+}
+
+func (a *Authenticator) flowConfig() *oauth2.Config {
+	conf := *a.oauthConfig
+	return &conf
+}
+
+func (a *Authenticator) contextWithHTTPClient(ctx context.Context) context.Context {
+	if a.httpClient == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, a.httpClient)
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"google-auth-wizard/config"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func newFakeBrowser(t *testing.T, fake *fakeGoogleServer, callbackQuery func(code, state string) string) func(string) error {
+	return func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Errorf("Expected valid auth URL, got %v", err)
+			return err
+		}
+
+		query := u.Query()
+		if query.Get("code_challenge_method") != "S256" {
+			t.Errorf("Expected code_challenge_method S256, got %q", query.Get("code_challenge_method"))
+		}
+		if query.Get("access_type") != "offline" {
+			t.Errorf("Expected access_type offline, got %q", query.Get("access_type"))
+		}
+
+		code := "code-" + query.Get("state")
+		fake.authorize(code, query.Get("code_challenge"))
+
+		go func() {
+			resp, err := http.Get(query.Get("redirect_uri") + "?" + callbackQuery(code, query.Get("state")))
+			if err != nil {
+				t.Errorf("Callback request failed: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+
+		return nil
+	}
+}
+
+func grantConsent(code, state string) string {
+	return "code=" + url.QueryEscape(code) + "&state=" + url.QueryEscape(state)
+}
+
+func newLoopbackTestConfig(port int) *config.Config {
+	cfg := config.GetDefaultConfig()
+	cfg.Server.DefaultPort = port
+	cfg.Server.ServerTimeout = 5 * time.Second
+	return cfg
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	oauthConfig := &oauth2.Config{ClientID: "test-client-id"}
+	httpClient := &http.Client{}
+
+	authenticator := NewAuthenticator(cfg, oauthConfig, WithHTTPClient(httpClient))
+
+	if authenticator.cfg != cfg {
+		t.Error("Expected config to be set")
+	}
+
+	if authenticator.oauthConfig != oauthConfig {
+		t.Error("Expected OAuth config to be set")
+	}
+
+	if authenticator.openBrowser == nil {
+		t.Error("Expected default browser opener")
+	}
+
+	if authenticator.httpClient != httpClient {
+		t.Error("Expected custom HTTP client to be set")
+	}
+}
+
+func TestAuthenticator_LocalServerToken(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+	oauthConfig := fake.oauthConfig()
+
+	authenticator := NewAuthenticator(newLoopbackTestConfig(18080), oauthConfig,
+		WithBrowserOpener(newFakeBrowser(t, fake, grantConsent)))
+
+	token, err := authenticator.LocalServerToken(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if token.AccessToken != "fake-access-token" {
+		t.Errorf("Expected access token 'fake-access-token', got %s", token.AccessToken)
+	}
+
+	if token.RefreshToken != "fake-refresh-token" {
+		t.Errorf("Expected refresh token 'fake-refresh-token', got %s", token.RefreshToken)
+	}
+
+	if oauthConfig.RedirectURL != "" {
+		t.Errorf("Expected caller's OAuth config to be left untouched, got RedirectURL %s", oauthConfig.RedirectURL)
+	}
+}
+
+func TestAuthenticator_LocalServerToken_Repeated(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+
+	authenticator := NewAuthenticator(newLoopbackTestConfig(18090), fake.oauthConfig(),
+		WithBrowserOpener(newFakeBrowser(t, fake, grantConsent)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := authenticator.Token(context.Background()); err != nil {
+			t.Fatalf("Run %d: expected no error, got %v", i+1, err)
+		}
+	}
+}
+
+func TestAuthenticator_LocalServerToken_Concurrent(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+	cfg := newLoopbackTestConfig(18100)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			authenticator := NewAuthenticator(cfg, fake.oauthConfig(),
+				WithBrowserOpener(newFakeBrowser(t, fake, grantConsent)))
+			if _, err := authenticator.LocalServerToken(context.Background()); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Expected concurrent runs to succeed, got %v", err)
+	}
+}
+
+func TestAuthenticator_LocalServerToken_LoopbackRedirect(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+
+	var redirectURI string
+	browser := newFakeBrowser(t, fake, grantConsent)
+
+	authenticator := NewAuthenticator(newLoopbackTestConfig(0), fake.oauthConfig(),
+		WithBrowserOpener(func(authURL string) error {
+			u, _ := url.Parse(authURL)
+			redirectURI = u.Query().Get("redirect_uri")
+			return browser(authURL)
+		}))
+
+	if _, err := authenticator.LocalServerToken(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	redirect, err := url.Parse(redirectURI)
+	if err != nil {
+		t.Fatalf("Expected valid redirect URI, got %v", err)
+	}
+
+	if redirect.Hostname() != "127.0.0.1" {
+		t.Errorf("Expected redirect to the IPv4 loopback address, got %s", redirect.Hostname())
+	}
+
+	if redirect.Port() == "" || redirect.Port() == "0" {
+		t.Errorf("Expected an OS-assigned port in the redirect URI, got %q", redirect.Port())
+	}
+}
+
+func TestAuthenticator_LocalServerToken_IPv6(t *testing.T) {
+	probe, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback not available")
+	}
+	_ = probe.Close()
+
+	fake := newFakeGoogleServer(t)
+	cfg := newLoopbackTestConfig(0)
+	cfg.Server.ListenIPv6 = true
+
+	authenticator := NewAuthenticator(cfg, fake.oauthConfig(),
+		WithBrowserOpener(func(authURL string) error {
+			u, _ := url.Parse(authURL)
+			query := u.Query()
+
+			redirect, _ := url.Parse(query.Get("redirect_uri"))
+			if redirect.Hostname() != "localhost" {
+				t.Errorf("Expected redirect to localhost when listening on both stacks, got %s", redirect.Hostname())
+			}
+
+			code := "code-ipv6"
+			fake.authorize(code, query.Get("code_challenge"))
+
+			go func() {
+				callbackURL := "http://[::1]:" + redirect.Port() + redirect.Path + "?" + grantConsent(code, query.Get("state"))
+				resp, err := http.Get(callbackURL)
+				if err != nil {
+					t.Errorf("IPv6 callback request failed: %v", err)
+					return
+				}
+				_ = resp.Body.Close()
+			}()
+			return nil
+		}))
+
+	if _, err := authenticator.LocalServerToken(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestAuthenticator_LocalServerToken_AccessDenied(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+
+	authenticator := NewAuthenticator(newLoopbackTestConfig(18120), fake.oauthConfig(),
+		WithBrowserOpener(newFakeBrowser(t, fake, func(_, state string) string {
+			return "error=access_denied&state=" + url.QueryEscape(state)
+		})))
+
+	_, err := authenticator.LocalServerToken(context.Background())
+
+	var authErr *AuthorizationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected AuthorizationError, got %v", err)
+	}
+
+	if authErr.Code != "access_denied" {
+		t.Errorf("Expected code access_denied, got %s", authErr.Code)
+	}
+}
+
+func TestAuthenticator_LocalServerToken_Cancelled(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	authenticator := NewAuthenticator(newLoopbackTestConfig(18130), fake.oauthConfig(),
+		WithBrowserOpener(func(string) error {
+			cancel()
+			return nil
+		}))
+
+	_, err := authenticator.LocalServerToken(ctx)
+	if err == nil || !strings.Contains(err.Error(), "authorization cancelled") {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+}
+
+func TestAuthenticator_UnknownFlow(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.OAuth.Flow = "carrier-pigeon"
+
+	_, err := NewAuthenticator(cfg, &oauth2.Config{}).Token(context.Background())
+	if err == nil {
+		t.Error("Expected error for unknown flow, got nil")
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google-auth-wizard/logger"
+	"io"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	DEVICE_ACCESS_DENIED_MSG = "device authorization was denied"
+	DEVICE_CODE_EXPIRED_MSG  = "device code expired before authorization completed"
+)
+
+func (a *Authenticator) DeviceToken(ctx context.Context) (*oauth2.Token, error) {
+	ctx = a.contextWithHTTPClient(ctx)
+
+	conf := a.flowConfig()
+	if conf.Endpoint.DeviceAuthURL == "" {
+		conf.Endpoint.DeviceAuthURL = a.cfg.OAuth.DeviceAuthURL
+	}
+	if conf.Endpoint.DeviceAuthURL == "" {
+		conf.Endpoint.DeviceAuthURL = google.Endpoint.DeviceAuthURL
+	}
+
+	logger.Debug("Requesting device code from %s", conf.Endpoint.DeviceAuthURL)
+	deviceAuth, err := conf.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting device code: %w", err)
+	}
+
+	printDeviceInstructions(a.out, deviceAuth)
+
+	logger.Debug("Polling token endpoint every %ds", deviceAuth.Interval)
+	token, err := conf.DeviceAccessToken(ctx, deviceAuth)
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			switch retrieveErr.ErrorCode {
+			case "access_denied":
+				return nil, fmt.Errorf("%s: %w", DEVICE_ACCESS_DENIED_MSG, &AuthorizationError{
+					Code:        retrieveErr.ErrorCode,
+					Description: retrieveErr.ErrorDescription,
+					URI:         retrieveErr.ErrorURI,
+				})
+			case "expired_token":
+				return nil, fmt.Errorf("%s: %w", DEVICE_CODE_EXPIRED_MSG, err)
+			}
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s", DEVICE_CODE_EXPIRED_MSG)
+		}
+		return nil, fmt.Errorf("error polling for device token: %w", err)
+	}
+
+	logger.Info("Authorization successful!")
+	return token, nil
+}
+
+func printDeviceInstructions(out io.Writer, deviceAuth *oauth2.DeviceAuthResponse) {
+	_, _ = fmt.Fprintln(out, "\nTo authorize this device, visit:")
+	_, _ = fmt.Fprintf(out, "  %s\n", deviceAuth.VerificationURI)
+	_, _ = fmt.Fprintln(out, "and enter the code:")
+	_, _ = fmt.Fprintf(out, "  %s\n", deviceAuth.UserCode)
+	if deviceAuth.VerificationURIComplete != "" {
+		_, _ = fmt.Fprintf(out, "\nOr open directly: %s\n", deviceAuth.VerificationURIComplete)
+	}
+	if !deviceAuth.Expiry.IsZero() {
+		_, _ = fmt.Fprintf(out, "\nThe code expires in %s.\n", time.Until(deviceAuth.Expiry).Round(time.Second))
+	}
+	_, _ = fmt.Fprintln(out)
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"google-auth-wizard/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+type fakeDeviceServer struct {
+	*httptest.Server
+	handler   http.Handler
+	mu        sync.Mutex
+	responses []string
+	polls     []time.Time
+}
+
+func newFakeDeviceServer(t *testing.T, responses ...string) *fakeDeviceServer {
+	f := &fakeDeviceServer{responses: responses}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device/code", f.handleDeviceCode)
+	mux.HandleFunc("/token", f.handleToken)
+	f.handler = mux
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeDeviceServer) handleDeviceCode(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") == "" {
+		writeTokenError(w, "invalid_client", "missing client_id")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"device_code":      "fake-device-code",
+		"user_code":        "ABCD-EFGH",
+		"verification_url": "https://www.google.com/device",
+		"expires_in":       60,
+		"interval":         1,
+	})
+}
+
+func (f *fakeDeviceServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	if r.PostForm.Get("device_code") != "fake-device-code" {
+		writeTokenError(w, "invalid_grant", "unknown device code")
+		return
+	}
+
+	f.mu.Lock()
+	f.polls = append(f.polls, time.Now())
+	response := "ok"
+	if len(f.responses) > 0 {
+		response = f.responses[0]
+		f.responses = f.responses[1:]
+	}
+	f.mu.Unlock()
+
+	if response != "ok" {
+		writeTokenError(w, response, response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "fake-device-access-token",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": "fake-device-refresh-token",
+	})
+}
+
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, r)
+	return recorder.Result(), nil
+}
+
+// client serves requests in memory so that tests running under synctest only
+// wait on the fake clock while the device flow sleeps between polls.
+func (f *fakeDeviceServer) client() *http.Client {
+	return &http.Client{Transport: handlerTransport{handler: f.handler}}
+}
+
+func (f *fakeDeviceServer) pollTimes() []time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Time(nil), f.polls...)
+}
+
+func (f *fakeDeviceServer) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     "test-client-id",
+		ClientSecret: "test-client-secret",
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  f.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{"https://www.googleapis.com/auth/drive.file"},
+	}
+}
+
+func newDeviceTestConfig(f *fakeDeviceServer) *config.Config {
+	cfg := config.GetDefaultConfig()
+	cfg.OAuth.Flow = config.FLOW_DEVICE
+	cfg.OAuth.DeviceAuthURL = f.URL + "/device/code"
+	return cfg
+}
+
+func deviceToken(t *testing.T, fake *fakeDeviceServer, out io.Writer) (token *oauth2.Token, err error) {
+	t.Helper()
+
+	synctest.Test(t, func(t *testing.T) {
+		authenticator := NewAuthenticator(newDeviceTestConfig(fake), fake.oauthConfig(), WithOutput(out), WithHTTPClient(fake.client()))
+		token, err = authenticator.Token(context.Background())
+	})
+	return token, err
+}
+
+func TestAuthenticator_DeviceToken_Success(t *testing.T) {
+	fake := newFakeDeviceServer(t, "authorization_pending")
+	var out bytes.Buffer
+
+	token, err := deviceToken(t, fake, &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if token.AccessToken != "fake-device-access-token" {
+		t.Errorf("Expected access token 'fake-device-access-token', got %s", token.AccessToken)
+	}
+
+	if token.RefreshToken != "fake-device-refresh-token" {
+		t.Errorf("Expected refresh token 'fake-device-refresh-token', got %s", token.RefreshToken)
+	}
+
+	if !strings.Contains(out.String(), "ABCD-EFGH") || !strings.Contains(out.String(), "https://www.google.com/device") {
+		t.Errorf("Expected verification URL and user code to be printed, got %s", out.String())
+	}
+
+	if polls := len(fake.pollTimes()); polls != 2 {
+		t.Errorf("Expected 2 polls (pending then success), got %d", polls)
+	}
+}
+
+func TestAuthenticator_DeviceToken_AccessDenied(t *testing.T) {
+	fake := newFakeDeviceServer(t, "access_denied")
+
+	_, err := deviceToken(t, fake, io.Discard)
+	if err == nil {
+		t.Fatal("Expected error when the user denies access, got nil")
+	}
+
+	if !strings.Contains(err.Error(), DEVICE_ACCESS_DENIED_MSG) {
+		t.Errorf("Expected access denied error, got %v", err)
+	}
+}
+
+func TestAuthenticator_DeviceToken_SlowDown(t *testing.T) {
+	fake := newFakeDeviceServer(t, "slow_down")
+
+	if _, err := deviceToken(t, fake, io.Discard); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	polls := fake.pollTimes()
+	if len(polls) != 2 {
+		t.Fatalf("Expected 2 polls, got %d", len(polls))
+	}
+
+	if gap := polls[1].Sub(polls[0]); gap < 5*time.Second {
+		t.Errorf("Expected polling interval to grow by 5s after slow_down, got %v", gap)
+	}
+}
+
+type recordingTransport struct {
+	http.RoundTripper
+	mu   sync.Mutex
+	urls []string
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.urls = append(t.urls, r.URL.String())
+	t.mu.Unlock()
+	return t.RoundTripper.RoundTrip(r)
+}
+
+func TestAuthenticator_DeviceToken_DefaultDeviceAuthURL(t *testing.T) {
+	fake := newFakeDeviceServer(t)
+	cfg := newDeviceTestConfig(fake)
+	cfg.OAuth.DeviceAuthURL = ""
+	transport := &recordingTransport{RoundTripper: handlerTransport{handler: fake.handler}}
+
+	synctest.Test(t, func(t *testing.T) {
+		authenticator := NewAuthenticator(cfg, fake.oauthConfig(), WithOutput(io.Discard), WithHTTPClient(&http.Client{Transport: transport}))
+		if _, err := authenticator.DeviceToken(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	if len(transport.urls) == 0 || transport.urls[0] != google.Endpoint.DeviceAuthURL {
+		t.Errorf("Expected the device code to be requested from %s, got %v", google.Endpoint.DeviceAuthURL, transport.urls)
+	}
+}
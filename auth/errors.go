@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type AuthorizationError struct {
+	Code        string
+	Description string
+	URI         string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("authorization failed: %s (%s)", e.Code, e.Description)
+	}
+	return fmt.Sprintf("authorization failed: %s", e.Code)
+}
+
+func (e *AuthorizationError) Title() string {
+	switch e.Code {
+	case "access_denied":
+		return "Access Denied"
+	case "invalid_scope":
+		return "Invalid Scope"
+	case "admin_policy_enforced":
+		return "Blocked by Administrator"
+	case "org_internal":
+		return "Organization Only Application"
+	case "unauthorized_client", "invalid_client":
+		return "Unauthorized Client"
+	case "disallowed_useragent":
+		return "Unsupported Browser"
+	default:
+		return "Authorization Failed"
+	}
+}
+
+func (e *AuthorizationError) Message() string {
+	var message string
+	switch e.Code {
+	case "access_denied":
+		message = "You declined the requested permissions. No token was issued."
+	case "invalid_scope":
+		message = "Google rejected one or more of the requested scopes."
+	case "admin_policy_enforced":
+		message = "Your Google Workspace administrator does not allow this application to access the requested scopes."
+	case "org_internal":
+		message = "This application is restricted to users of the Google Workspace organization that owns it."
+	case "unauthorized_client", "invalid_client":
+		message = "This OAuth client is not allowed to perform this authorization request."
+	case "disallowed_useragent":
+		message = "Google does not allow authorization from this browser. Open the link in a standard browser."
+	default:
+		message = fmt.Sprintf("Google returned the error %q.", e.Code)
+	}
+
+	if e.Description != "" {
+		message += " " + e.Description
+	}
+	return message + " You can close this window and return to the terminal."
+}
+
+func authorizationErrorFromQuery(query url.Values) *AuthorizationError {
+	code := query.Get("error")
+	if code == "" {
+		return nil
+	}
+
+	return &AuthorizationError{
+		Code:        code,
+		Description: query.Get("error_description"),
+		URI:         query.Get("error_uri"),
+	}
+}
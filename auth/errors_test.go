@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizationErrorFromQuery(t *testing.T) {
+	query := url.Values{}
+	if authErr := authorizationErrorFromQuery(query); authErr != nil {
+		t.Errorf("Expected nil for query without error, got %v", authErr)
+	}
+
+	query.Set("error", "access_denied")
+	query.Set("error_description", "The user denied access")
+	query.Set("error_uri", "https://example.com/help")
+
+	authErr := authorizationErrorFromQuery(query)
+	if authErr == nil {
+		t.Fatal("Expected authorization error, got nil")
+	}
+
+	if authErr.Code != "access_denied" {
+		t.Errorf("Expected code 'access_denied', got %s", authErr.Code)
+	}
+
+	if authErr.Description != "The user denied access" {
+		t.Errorf("Expected description to be set, got %s", authErr.Description)
+	}
+
+	if authErr.URI != "https://example.com/help" {
+		t.Errorf("Expected URI to be set, got %s", authErr.URI)
+	}
+
+	if !strings.Contains(authErr.Error(), "access_denied") {
+		t.Errorf("Expected error message to contain the code, got %s", authErr.Error())
+	}
+}
+
+func TestAuthorizationError_TitleAndMessage(t *testing.T) {
+	tests := []struct {
+		code          string
+		expectedTitle string
+	}{
+		{code: "access_denied", expectedTitle: "Access Denied"},
+		{code: "invalid_scope", expectedTitle: "Invalid Scope"},
+		{code: "admin_policy_enforced", expectedTitle: "Blocked by Administrator"},
+		{code: "org_internal", expectedTitle: "Organization Only Application"},
+		{code: "something_new", expectedTitle: "Authorization Failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			authErr := &AuthorizationError{Code: tt.code}
+
+			if authErr.Title() != tt.expectedTitle {
+				t.Errorf("Expected title %q, got %q", tt.expectedTitle, authErr.Title())
+			}
+
+			if authErr.Message() == "" {
+				t.Error("Expected a non-empty message")
+			}
+		})
+	}
+
+	unknown := &AuthorizationError{Code: "something_new", Description: "extra details"}
+	if !strings.Contains(unknown.Message(), "something_new") || !strings.Contains(unknown.Message(), "extra details") {
+		t.Errorf("Expected message to include the code and description, got %s", unknown.Message())
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"google-auth-wizard/logger"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	MANUAL_INPUT_PROMPT = "Paste the full redirect URL (or just the code) and press Enter: "
+	EMPTY_INPUT_MSG     = "no redirect URL or authorization code provided"
+)
+
+func (a *Authenticator) ManualToken(ctx context.Context) (*oauth2.Token, error) {
+	ctx = a.contextWithHTTPClient(ctx)
+
+	conf := a.flowConfig()
+	conf.RedirectURL = "http://localhost" + a.cfg.OAuth.CallbackPath
+	if a.cfg.Server.DefaultPort != 0 {
+		conf.RedirectURL = fmt.Sprintf("http://localhost:%d%s", a.cfg.Server.DefaultPort, a.cfg.OAuth.CallbackPath)
+	}
+	logger.Debug("Redirect URL: %s", conf.RedirectURL)
+
+	state, err := generateState()
+	if err != nil {
+		return nil, fmt.Errorf("error generating state token: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
+
+	_, _ = fmt.Fprintln(a.out, "\nOpen the following URL in a browser on any machine:")
+	_, _ = fmt.Fprintf(a.out, "  %s\n\n", authURL)
+	_, _ = fmt.Fprintf(a.out, "After granting access the browser is redirected to %s, which will fail to load.\n", conf.RedirectURL)
+	_, _ = fmt.Fprintln(a.out, "Copy the URL from the address bar.")
+	_, _ = fmt.Fprint(a.out, MANUAL_INPUT_PROMPT)
+
+	line, err := a.readLine(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := parseManualInput(line, state)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := exchangeCode(ctx, conf, code, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", CODE_EXCHANGE_FAILED_MSG, err)
+	}
+
+	logger.Info("Authorization successful!")
+	return token, nil
+}
+
+func (a *Authenticator) readLine(ctx context.Context) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+
+	resultChan := make(chan result, 1)
+	go func() {
+		line, err := bufio.NewReader(a.in).ReadString('\n')
+		resultChan <- result{line: line, err: err}
+	}()
+
+	select {
+	case res := <-resultChan:
+		if res.err != nil && res.err != io.EOF {
+			return "", fmt.Errorf("error reading input: %w", res.err)
+		}
+		return res.line, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("authorization cancelled: %w", ctx.Err())
+	}
+}
+
+func parseManualInput(input, expectedState string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("%s", EMPTY_INPUT_MSG)
+	}
+
+	if !strings.Contains(input, "=") {
+		if code, err := url.QueryUnescape(input); err == nil {
+			return code, nil
+		}
+		return input, nil
+	}
+
+	rawQuery := input
+	if idx := strings.Index(input, "?"); idx >= 0 {
+		rawQuery = input[idx+1:]
+	}
+	rawQuery, _, _ = strings.Cut(rawQuery, "#")
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("error parsing redirect URL: %w", err)
+	}
+
+	if !validState(query.Get("state"), expectedState) {
+		return "", fmt.Errorf("%s: the pasted URL does not belong to this authorization request", STATE_MISMATCH_MSG)
+	}
+
+	if authErr := authorizationErrorFromQuery(query); authErr != nil {
+		return "", authErr
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("%s", MISSING_AUTH_CODE_MSG)
+	}
+
+	return code, nil
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"google-auth-wizard/config"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseManualInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		errContains string
+	}{
+		{
+			name:     "Full Redirect URL",
+			input:    "http://localhost:8080/callback?state=expected-state&code=4%2F0Abc&scope=email\n",
+			expected: "4/0Abc",
+		},
+		{
+			name:     "Query String Only",
+			input:    "  code=4%2F0Abc&state=expected-state  ",
+			expected: "4/0Abc",
+		},
+		{
+			name:     "Bare Code",
+			input:    "4/0Abc\n",
+			expected: "4/0Abc",
+		},
+		{
+			name:     "Bare Encoded Code",
+			input:    "4%2F0Abc",
+			expected: "4/0Abc",
+		},
+		{
+			name:        "State Mismatch",
+			input:       "http://localhost:8080/callback?state=other-state&code=4%2F0Abc",
+			errContains: STATE_MISMATCH_MSG,
+		},
+		{
+			name:        "Missing State",
+			input:       "http://localhost:8080/callback?code=4%2F0Abc",
+			errContains: STATE_MISMATCH_MSG,
+		},
+		{
+			name:        "Consent Cancelled",
+			input:       "http://localhost:8080/callback?error=access_denied&state=expected-state",
+			errContains: "access_denied",
+		},
+		{
+			name:        "Missing Code",
+			input:       "http://localhost:8080/callback?state=expected-state",
+			errContains: MISSING_AUTH_CODE_MSG,
+		},
+		{
+			name:        "Empty Input",
+			input:       "\n",
+			errContains: EMPTY_INPUT_MSG,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := parseManualInput(tt.input, "expected-state")
+
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if code != tt.expected {
+				t.Errorf("Expected code %q, got %q", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_ManualToken(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+	cfg := config.GetDefaultConfig()
+
+	outReader, outWriter := io.Pipe()
+	inReader, inWriter := io.Pipe()
+
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, fake.URL) {
+				continue
+			}
+
+			authURL, err := url.Parse(line)
+			if err != nil {
+				t.Errorf("Expected valid auth URL, got %v", err)
+				return
+			}
+
+			query := authURL.Query()
+			fake.authorize("manual-code", query.Get("code_challenge"))
+
+			go func() {
+				_, _ = io.Copy(io.Discard, outReader)
+			}()
+
+			_, _ = fmt.Fprintf(inWriter, "%s?state=%s&code=manual-code\n",
+				query.Get("redirect_uri"), url.QueryEscape(query.Get("state")))
+			return
+		}
+	}()
+
+	authenticator := NewAuthenticator(cfg, fake.oauthConfig(), WithInput(inReader), WithOutput(outWriter))
+
+	token, err := authenticator.ManualToken(context.Background())
+	_ = outWriter.Close()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if token.AccessToken != "fake-access-token" {
+		t.Errorf("Expected access token 'fake-access-token', got %s", token.AccessToken)
+	}
+}
+
+func TestAuthenticator_ManualToken_Cancelled(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+	inReader, inWriter := io.Pipe()
+	defer func() {
+		_ = inWriter.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	authenticator := NewAuthenticator(config.GetDefaultConfig(), fake.oauthConfig(), WithInput(inReader), WithOutput(io.Discard))
+
+	_, err := authenticator.ManualToken(ctx)
+	if err == nil || !strings.Contains(err.Error(), "authorization cancelled") {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+}
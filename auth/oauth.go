@@ -2,19 +2,21 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"google-auth-wizard/config"
 	"google-auth-wizard/logger"
-	"google-auth-wizard/utils"
+	"html"
 	"net/http"
-	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
 const (
-	DEFAULT_STATE_TOKEN = "state-token"
+	STATE_TOKEN_BYTES = 32
 
 	SUCCESS_HTML_TEMPLATE = `<!DOCTYPE html>
 <html>
@@ -32,9 +34,25 @@ const (
 </body>
 </html>`
 
-	MISSING_AUTH_CODE_MSG        = "missing authorization code"
-	CODE_EXCHANGE_FAILED_MSG     = "code exchange failed"
-	DEFAULT_SERVER_STARTUP_DELAY = 100 * time.Millisecond
+	ERROR_HTML_TEMPLATE = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Authorization Failed</title>
+    <style>
+        body { font-family: Arial, sans-serif; text-align: center; padding: 50px; }
+        .error { color: #c0392b; font-size: 24px; margin-bottom: 20px; }
+        .info { color: #666; }
+    </style>
+</head>
+<body>
+    <div class="error">❌ %s</div>
+    <div class="info">%s</div>
+</body>
+</html>`
+
+	STATE_MISMATCH_MSG       = "state parameter mismatch"
+	MISSING_AUTH_CODE_MSG    = "missing authorization code"
+	CODE_EXCHANGE_FAILED_MSG = "code exchange failed"
 )
 
 func CreateOAuthConfig(credentials []byte, selectedScopes []string) (*oauth2.Config, error) {
@@ -45,77 +63,101 @@ func CreateOAuthConfig(credentials []byte, selectedScopes []string) (*oauth2.Con
 	return config, nil
 }
 
-func GetTokenFromLocalServer(cfg *config.Config, config *oauth2.Config) (*oauth2.Token, error) {
-	port, err := utils.FindAvailablePort(cfg.Server.DefaultPort, cfg.Server.MaxPortTries)
-	if err != nil {
-		return nil, fmt.Errorf("error finding available port: %w", err)
-	}
-
-	config.RedirectURL = fmt.Sprintf("http://localhost:%d%s", port, cfg.OAuth.CallbackPath)
-
-	logger.Info("Using port %d for OAuth callback", port)
-	logger.Debug("Redirect URL: %s", config.RedirectURL)
-
-	tokenChan := make(chan *oauth2.Token, 1)
-	errChan := make(chan error, 1)
-
-	serverAddr := fmt.Sprintf(":%d", port)
-	srv := &http.Server{Addr: serverAddr}
-
-	http.HandleFunc(cfg.OAuth.CallbackPath, createCallbackHandler(config, tokenChan, errChan))
-
-	go func() {
-		logger.Debug("Starting OAuth callback server on port %d...", port)
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("server error: %v", err)
-		}
-	}()
-
-	time.Sleep(DEFAULT_SERVER_STARTUP_DELAY)
-
-	authURL := config.AuthCodeURL(DEFAULT_STATE_TOKEN, oauth2.AccessTypeOffline)
-	logger.Info("Opening browser to: %s", authURL)
-
-	if err := utils.OpenBrowser(authURL); err != nil {
-		logger.Info("Unable to open browser automatically. Please open manually: %s", authURL)
+func ProjectIDFromCredentials(credentials []byte) string {
+	var file struct {
+		Installed *struct {
+			ProjectID string `json:"project_id"`
+		} `json:"installed"`
+		Web *struct {
+			ProjectID string `json:"project_id"`
+		} `json:"web"`
 	}
 
-	var token *oauth2.Token
-	select {
-	case token = <-tokenChan:
-		logger.Info("Authorization successful!")
-	case err := <-errChan:
-		return nil, fmt.Errorf("authorization error: %w", err)
-	case <-time.After(cfg.Server.ServerTimeout):
-		return nil, fmt.Errorf("timeout: authorization not received within %v", cfg.Server.ServerTimeout)
+	if err := json.Unmarshal(credentials, &file); err != nil {
+		return ""
 	}
 
-	if err := srv.Shutdown(context.Background()); err != nil {
-		logger.Debug("Error shutting down server: %v", err)
+	switch {
+	case file.Installed != nil:
+		return file.Installed.ProjectID
+	case file.Web != nil:
+		return file.Web.ProjectID
+	default:
+		return ""
 	}
-
-	return token, nil
 }
 
-func createCallbackHandler(config *oauth2.Config, tokenChan chan<- *oauth2.Token, errChan chan<- error) http.HandlerFunc {
+func createCallbackHandler(ctx context.Context, config *oauth2.Config, state, verifier string, tokenChan chan<- *oauth2.Token, errChan chan<- error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !validState(r.URL.Query().Get("state"), state) {
+			sendError(errChan, fmt.Errorf("%s: the callback did not originate from this authorization request", STATE_MISMATCH_MSG))
+			renderErrorPage(w, http.StatusBadRequest, "Invalid Authorization Response",
+				"The state parameter does not match this session. Please restart the authorization from the terminal.")
+			return
+		}
+
+		if authErr := authorizationErrorFromQuery(r.URL.Query()); authErr != nil {
+			sendError(errChan, authErr)
+			renderErrorPage(w, http.StatusBadRequest, authErr.Title(), authErr.Message())
+			return
+		}
+
 		code := r.URL.Query().Get("code")
 		if code == "" {
-			errChan <- fmt.Errorf("%s", MISSING_AUTH_CODE_MSG)
-			http.Error(w, "Missing authorization code", http.StatusBadRequest)
+			sendError(errChan, fmt.Errorf("%s", MISSING_AUTH_CODE_MSG))
+			renderErrorPage(w, http.StatusBadRequest, "Missing Authorization Code",
+				"Google did not return an authorization code. Please restart the authorization from the terminal.")
 			return
 		}
 
-		token, err := config.Exchange(context.Background(), code)
+		token, err := exchangeCode(ctx, config, code, verifier)
 		if err != nil {
-			errChan <- fmt.Errorf("%s: %v", CODE_EXCHANGE_FAILED_MSG, err)
-			http.Error(w, "Code exchange failed", http.StatusInternalServerError)
+			sendError(errChan, fmt.Errorf("%s: %v", CODE_EXCHANGE_FAILED_MSG, err))
+			renderErrorPage(w, http.StatusInternalServerError, "Code Exchange Failed",
+				"The authorization code could not be exchanged for a token. Check the terminal for details.")
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_, _ = fmt.Fprint(w, SUCCESS_HTML_TEMPLATE)
 
-		tokenChan <- token
+		select {
+		case tokenChan <- token:
+		default:
+			logger.Debug("Ignoring callback received after the authorization completed")
+		}
 	}
 }
+
+func sendError(errChan chan<- error, err error) {
+	select {
+	case errChan <- err:
+	default:
+		logger.Debug("Ignoring callback error after the authorization completed: %v", err)
+	}
+}
+
+func exchangeCode(ctx context.Context, config *oauth2.Config, code, verifier string) (*oauth2.Token, error) {
+	return config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+}
+
+func generateState() (string, error) {
+	data := make([]byte, STATE_TOKEN_BYTES)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func validState(received, expected string) bool {
+	if received == "" || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(received), []byte(expected)) == 1
+}
+
+func renderErrorPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, ERROR_HTML_TEMPLATE, html.EscapeString(title), html.EscapeString(message))
+}
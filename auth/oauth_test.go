@@ -1,10 +1,13 @@
 package auth
 
 import (
-	"google-auth-wizard/config"
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -49,39 +52,133 @@ func TestCreateOAuthConfig_InvalidJSON(t *testing.T) {
 	}
 }
 
-func TestGetTokenFromLocalServer_Success(t *testing.T) {
-	cfg := &config.Config{
-		Server: struct {
-			DefaultPort   int           `yaml:"defaultPort"`
-			MaxPortTries  int           `yaml:"maxPortTries"`
-			ServerTimeout time.Duration `yaml:"serverTimeout"`
-		}{
-			DefaultPort:   8080,
-			MaxPortTries:  10,
-			ServerTimeout: 5 * time.Second, // Court pour les tests
-		},
-		OAuth: struct {
-			CallbackPath       string        `yaml:"callbackPath"`
-			OAuthPlaygroundURL string        `yaml:"oauthPlaygroundURL"`
-			ScopeEndpoint      string        `yaml:"scopeEndpoint"`
-			ScopeTimeout       time.Duration `yaml:"scopeTimeout"`
-		}{
-			CallbackPath: "/callback",
-		},
+func TestProjectIDFromCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials string
+		expected    string
+	}{
+		{"Installed", `{"installed": {"client_id": "id", "project_id": "desktop-project"}}`, "desktop-project"},
+		{"Web", `{"web": {"client_id": "id", "project_id": "web-project"}}`, "web-project"},
+		{"Missing", `{"installed": {"client_id": "id"}}`, ""},
+		{"Invalid JSON", `invalid json`, ""},
 	}
 
-	oauthConfig := &oauth2.Config{
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if projectID := ProjectIDFromCredentials([]byte(tt.credentials)); projectID != tt.expected {
+				t.Errorf("Expected project ID %q, got %q", tt.expected, projectID)
+			}
+		})
+	}
+}
+
+type fakeGoogleServer struct {
+	*httptest.Server
+	mu         sync.Mutex
+	challenges map[string]string
+	refreshes  int
+}
+
+func newFakeGoogleServer(t *testing.T) *fakeGoogleServer {
+	f := &fakeGoogleServer{challenges: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", f.handleToken)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeGoogleServer) authorize(code, challenge string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.challenges[code] = challenge
+}
+
+func (f *fakeGoogleServer) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
 		ClientID:     "test-client-id",
 		ClientSecret: "test-client-secret",
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
-			TokenURL: "https://oauth2.googleapis.com/token",
+			AuthURL:   f.URL + "/auth",
+			TokenURL:  f.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
 		},
 		Scopes: []string{"https://www.googleapis.com/auth/gmail.readonly"},
 	}
+}
+
+func (f *fakeGoogleServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	if r.PostForm.Get("grant_type") == "refresh_token" {
+		f.handleRefresh(w, r)
+		return
+	}
 
-	_ = cfg
-	_ = oauthConfig
+	f.mu.Lock()
+	challenge, ok := f.challenges[r.PostForm.Get("code")]
+	f.mu.Unlock()
+	if !ok {
+		writeTokenError(w, "invalid_grant", "unknown authorization code")
+		return
+	}
+
+	verifier := r.PostForm.Get("code_verifier")
+	if verifier == "" {
+		writeTokenError(w, "invalid_request", "missing code_verifier")
+		return
+	}
+	if oauth2.S256ChallengeFromVerifier(verifier) != challenge {
+		writeTokenError(w, "invalid_grant", "code_verifier does not match code_challenge")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "fake-access-token",
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": "fake-refresh-token",
+	})
+}
+
+func (f *fakeGoogleServer) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.PostForm.Get("refresh_token") != "fake-refresh-token" {
+		writeTokenError(w, "invalid_grant", "Token has been expired or revoked.")
+		return
+	}
+
+	f.mu.Lock()
+	f.refreshes++
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "refreshed-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (f *fakeGoogleServer) refreshCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refreshes
+}
+
+func writeTokenError(w http.ResponseWriter, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
 }
 
 func TestCreateCallbackHandler(t *testing.T) {
@@ -98,10 +195,96 @@ func TestCreateCallbackHandler(t *testing.T) {
 	tokenChan := make(chan *oauth2.Token, 1)
 	errChan := make(chan error, 1)
 
-	handler := createCallbackHandler(oauthConfig, tokenChan, errChan)
+	handler := createCallbackHandler(context.Background(), oauthConfig, "test-state", oauth2.GenerateVerifier(), tokenChan, errChan)
+
+	t.Run("Missing State", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/callback?code=test-code", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		select {
+		case err := <-errChan:
+			if !strings.Contains(err.Error(), "state parameter mismatch") {
+				t.Errorf("Expected state mismatch error, got %v", err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Error("Expected error to be sent to errChan")
+		}
+	})
+
+	t.Run("Mismatched State", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/callback?code=test-code&state=attacker-state", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		if !strings.Contains(w.Body.String(), "Invalid Authorization Response") {
+			t.Errorf("Expected HTML error page, got %s", w.Body.String())
+		}
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("Expected text/html content type, got %s", ct)
+		}
+
+		select {
+		case err := <-errChan:
+			if !strings.Contains(err.Error(), "state parameter mismatch") {
+				t.Errorf("Expected state mismatch error, got %v", err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Error("Expected error to be sent to errChan")
+		}
+	})
+
+	t.Run("Access Denied", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/callback?error=access_denied&state=test-state", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		if !strings.Contains(w.Body.String(), "Access Denied") {
+			t.Errorf("Expected access denied HTML page, got %s", w.Body.String())
+		}
+
+		select {
+		case err := <-errChan:
+			var authErr *AuthorizationError
+			if !errors.As(err, &authErr) || authErr.Code != "access_denied" {
+				t.Errorf("Expected AuthorizationError with code access_denied, got %v", err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Error("Expected error to be sent to errChan")
+		}
+	})
+
+	t.Run("Error Description Is Escaped", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/callback?error=invalid_scope&error_description=%3Cscript%3E&state=test-state", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if strings.Contains(w.Body.String(), "<script>") {
+			t.Error("Expected error description to be HTML escaped")
+		}
+
+		<-errChan
+	})
 
 	t.Run("Missing Code", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/callback", nil)
+		req := httptest.NewRequest("GET", "/callback?state=test-state", nil)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -121,7 +304,7 @@ func TestCreateCallbackHandler(t *testing.T) {
 	})
 
 	t.Run("With Code but Invalid Exchange", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/callback?code=test-code", nil)
+		req := httptest.NewRequest("GET", "/callback?code=test-code&state=test-state", nil)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -141,9 +324,86 @@ func TestCreateCallbackHandler(t *testing.T) {
 	})
 }
 
+func TestCreateCallbackHandler_PKCE(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+	verifier := oauth2.GenerateVerifier()
+	fake.authorize("pkce-code", oauth2.S256ChallengeFromVerifier(verifier))
+
+	tests := []struct {
+		name        string
+		verifier    string
+		expectToken bool
+	}{
+		{name: "Matching Verifier", verifier: verifier, expectToken: true},
+		{name: "Missing Verifier", verifier: "", expectToken: false},
+		{name: "Wrong Verifier", verifier: oauth2.GenerateVerifier(), expectToken: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenChan := make(chan *oauth2.Token, 1)
+			errChan := make(chan error, 1)
+
+			handler := createCallbackHandler(context.Background(), fake.oauthConfig(), "pkce-state", tt.verifier, tokenChan, errChan)
+
+			req := httptest.NewRequest("GET", "/callback?code=pkce-code&state=pkce-state", nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if tt.expectToken {
+				if w.Code != http.StatusOK {
+					t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+				}
+				select {
+				case token := <-tokenChan:
+					if token.AccessToken != "fake-access-token" {
+						t.Errorf("Expected access token 'fake-access-token', got %s", token.AccessToken)
+					}
+				case err := <-errChan:
+					t.Errorf("Expected token, got error %v", err)
+				}
+				return
+			}
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+			}
+			select {
+			case err := <-errChan:
+				if !strings.Contains(err.Error(), "code exchange failed") {
+					t.Errorf("Expected code exchange error, got %v", err)
+				}
+			case <-tokenChan:
+				t.Error("Expected exchange to be rejected without the matching verifier")
+			}
+		})
+	}
+}
+
+func TestGenerateState(t *testing.T) {
+	first, err := generateState()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	second, err := generateState()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if first == second {
+		t.Error("Expected each flow to get a distinct state token")
+	}
+
+	if len(first) < STATE_TOKEN_BYTES {
+		t.Errorf("Expected state token of at least %d characters, got %d", STATE_TOKEN_BYTES, len(first))
+	}
+}
+
 func TestConstants(t *testing.T) {
-	if DEFAULT_STATE_TOKEN == "" {
-		t.Error("DEFAULT_STATE_TOKEN should not be empty")
+	if STATE_TOKEN_BYTES < 16 {
+		t.Error("STATE_TOKEN_BYTES should provide at least 128 bits of entropy")
 	}
 
 	if SUCCESS_HTML_TEMPLATE == "" {
@@ -154,6 +414,10 @@ func TestConstants(t *testing.T) {
 		t.Error("SUCCESS_HTML_TEMPLATE should contain success message")
 	}
 
+	if !strings.Contains(ERROR_HTML_TEMPLATE, "%s") {
+		t.Error("ERROR_HTML_TEMPLATE should contain placeholders for the error details")
+	}
+
 	if MISSING_AUTH_CODE_MSG == "" {
 		t.Error("MISSING_AUTH_CODE_MSG should not be empty")
 	}
@@ -161,8 +425,4 @@ func TestConstants(t *testing.T) {
 	if CODE_EXCHANGE_FAILED_MSG == "" {
 		t.Error("CODE_EXCHANGE_FAILED_MSG should not be empty")
 	}
-
-	if DEFAULT_SERVER_STARTUP_DELAY <= 0 {
-		t.Error("DEFAULT_SERVER_STARTUP_DELAY should be positive")
-	}
 }
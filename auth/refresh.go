@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	INVALID_GRANT_ERROR       = "invalid_grant"
+	MISSING_REFRESH_TOKEN_MSG = "token has no refresh token"
+)
+
+func RefreshToken(ctx context.Context, oauthConfig *oauth2.Config, token *oauth2.Token) (*oauth2.Token, error) {
+	if token == nil || token.RefreshToken == "" {
+		return nil, fmt.Errorf("%s", MISSING_REFRESH_TOKEN_MSG)
+	}
+
+	refreshed, err := oauthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return refreshed, nil
+}
+
+func IsInvalidGrant(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	return errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == INVALID_GRANT_ERROR
+}
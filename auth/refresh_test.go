@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRefreshToken_Success(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+
+	expired := &oauth2.Token{
+		AccessToken:  "expired-access-token",
+		RefreshToken: "fake-refresh-token",
+	}
+
+	token, err := RefreshToken(context.Background(), fake.oauthConfig(), expired)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if token.AccessToken != "refreshed-access-token" {
+		t.Errorf("Expected refreshed access token, got %s", token.AccessToken)
+	}
+
+	if token.RefreshToken != "fake-refresh-token" {
+		t.Errorf("Expected refresh token to be preserved, got %s", token.RefreshToken)
+	}
+
+	if token.Expiry.IsZero() {
+		t.Error("Expected refreshed token to have an expiry")
+	}
+}
+
+func TestRefreshToken_InvalidGrant(t *testing.T) {
+	fake := newFakeGoogleServer(t)
+
+	revoked := &oauth2.Token{RefreshToken: "revoked-refresh-token"}
+
+	_, err := RefreshToken(context.Background(), fake.oauthConfig(), revoked)
+	if err == nil {
+		t.Fatal("Expected error for revoked refresh token, got nil")
+	}
+
+	if !IsInvalidGrant(err) {
+		t.Errorf("Expected invalid_grant error, got %v", err)
+	}
+}
+
+func TestRefreshToken_MissingRefreshToken(t *testing.T) {
+	_, err := RefreshToken(context.Background(), &oauth2.Config{}, &oauth2.Token{AccessToken: "access-only"})
+	if err == nil || !strings.Contains(err.Error(), MISSING_REFRESH_TOKEN_MSG) {
+		t.Errorf("Expected missing refresh token error, got %v", err)
+	}
+
+	if IsInvalidGrant(err) {
+		t.Error("Missing refresh token should not be reported as invalid_grant")
+	}
+}
+
+func TestIsInvalidGrant(t *testing.T) {
+	if IsInvalidGrant(errors.New("network unreachable")) {
+		t.Error("Expected plain errors not to be invalid_grant")
+	}
+
+	if !IsInvalidGrant(&oauth2.RetrieveError{ErrorCode: "invalid_grant"}) {
+		t.Error("Expected RetrieveError with invalid_grant to be detected")
+	}
+
+	if IsInvalidGrant(&oauth2.RetrieveError{ErrorCode: "invalid_client"}) {
+		t.Error("Expected other OAuth errors not to be invalid_grant")
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	INVALID_TOKEN_ERROR    = "invalid_token"
+	MISSING_TOKEN_MSG      = "no token to revoke"
+	MISSING_REVOKE_URL_MSG = "no revocation endpoint configured (oauth.revokeURL)"
+	REVOKE_BODY_MAX_BYTES  = 1 << 20
+	HTTP_CLIENT_TIMEOUT    = 30 * time.Second
+)
+
+var defaultHTTPClient = &http.Client{Timeout: HTTP_CLIENT_TIMEOUT}
+
+type RevokeError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *RevokeError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("revocation failed with status %d", e.StatusCode)
+	}
+	if e.Description != "" {
+		return fmt.Sprintf("revocation failed: %s (%s)", e.Code, e.Description)
+	}
+	return fmt.Sprintf("revocation failed: %s", e.Code)
+}
+
+func (e *RevokeError) AlreadyInvalid() bool {
+	return e.Code == INVALID_TOKEN_ERROR
+}
+
+func RevokeToken(ctx context.Context, revokeURL string, token *oauth2.Token) error {
+	if revokeURL == "" {
+		return fmt.Errorf("%s", MISSING_REVOKE_URL_MSG)
+	}
+
+	value := revocableToken(token)
+	if value == "" {
+		return fmt.Errorf("%s", MISSING_TOKEN_MSG)
+	}
+
+	form := url.Values{"token": {value}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create revocation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClientFromContext(ctx).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach revocation endpoint: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	revokeErr := &RevokeError{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, REVOKE_BODY_MAX_BYTES))
+
+	var payload struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		revokeErr.Code = payload.Error
+		revokeErr.Description = payload.ErrorDescription
+	}
+
+	return revokeErr
+}
+
+func revocableToken(token *oauth2.Token) string {
+	if token == nil {
+		return ""
+	}
+	if token.RefreshToken != "" {
+		return token.RefreshToken
+	}
+	return token.AccessToken
+}
+
+func httpClientFromContext(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && client != nil {
+		return client
+	}
+	return defaultHTTPClient
+}
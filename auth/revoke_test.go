@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+type fakeRevokeServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	revoked []string
+}
+
+func newFakeRevokeServer(t *testing.T) *fakeRevokeServer {
+	f := &fakeRevokeServer{}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.ParseForm() != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		token := r.PostForm.Get("token")
+		if token == "" || strings.HasPrefix(token, "stale-") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_token","error_description":"Token expired or revoked"}`))
+			return
+		}
+
+		f.mu.Lock()
+		f.revoked = append(f.revoked, token)
+		f.mu.Unlock()
+	}))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeRevokeServer) revokedTokens() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.revoked...)
+}
+
+func TestRevokeToken_PrefersRefreshToken(t *testing.T) {
+	fake := newFakeRevokeServer(t)
+
+	token := &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"}
+	if err := RevokeToken(context.Background(), fake.URL, token); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	revoked := fake.revokedTokens()
+	if len(revoked) != 1 || revoked[0] != "refresh-token" {
+		t.Errorf("Expected the refresh token to be revoked, got %v", revoked)
+	}
+}
+
+func TestRevokeToken_AccessTokenOnly(t *testing.T) {
+	fake := newFakeRevokeServer(t)
+
+	if err := RevokeToken(context.Background(), fake.URL, &oauth2.Token{AccessToken: "access-token"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	revoked := fake.revokedTokens()
+	if len(revoked) != 1 || revoked[0] != "access-token" {
+		t.Errorf("Expected the access token to be revoked, got %v", revoked)
+	}
+}
+
+func TestRevokeToken_AlreadyInvalid(t *testing.T) {
+	fake := newFakeRevokeServer(t)
+
+	err := RevokeToken(context.Background(), fake.URL, &oauth2.Token{RefreshToken: "stale-refresh-token"})
+
+	var revokeErr *RevokeError
+	if !errors.As(err, &revokeErr) {
+		t.Fatalf("Expected RevokeError, got %v", err)
+	}
+
+	if !revokeErr.AlreadyInvalid() {
+		t.Errorf("Expected invalid_token to be reported as already invalid, got %s", revokeErr.Code)
+	}
+
+	if revokeErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", revokeErr.StatusCode)
+	}
+}
+
+func TestRevokeToken_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := RevokeToken(context.Background(), server.URL, &oauth2.Token{RefreshToken: "refresh-token"})
+
+	var revokeErr *RevokeError
+	if !errors.As(err, &revokeErr) {
+		t.Fatalf("Expected RevokeError, got %v", err)
+	}
+
+	if revokeErr.AlreadyInvalid() {
+		t.Error("Expected a server error not to be reported as already invalid")
+	}
+}
+
+func TestRevokeToken_MissingToken(t *testing.T) {
+	for _, token := range []*oauth2.Token{nil, {}} {
+		err := RevokeToken(context.Background(), "http://127.0.0.1:0", token)
+		if err == nil || !strings.Contains(err.Error(), MISSING_TOKEN_MSG) {
+			t.Errorf("Expected missing token error, got %v", err)
+		}
+	}
+}
+
+func TestRevokeToken_MissingRevokeURL(t *testing.T) {
+	err := RevokeToken(context.Background(), "", &oauth2.Token{RefreshToken: "refresh-token"})
+	if err == nil || !strings.Contains(err.Error(), MISSING_REVOKE_URL_MSG) {
+		t.Errorf("Expected missing revocation endpoint error, got %v", err)
+	}
+}
+
+func TestHTTPClientFromContext(t *testing.T) {
+	if client := httpClientFromContext(context.Background()); client.Timeout != HTTP_CLIENT_TIMEOUT {
+		t.Errorf("Expected the default client to time out after %v, got %v", HTTP_CLIENT_TIMEOUT, client.Timeout)
+	}
+
+	custom := &http.Client{}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, custom)
+	if client := httpClientFromContext(ctx); client != custom {
+		t.Error("Expected the client from the context to be used")
+	}
+}
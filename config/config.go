@@ -11,20 +11,65 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	FLOW_LOOPBACK = "loopback"
+	FLOW_DEVICE   = "device"
+	FLOW_MANUAL   = "manual"
+
+	STORAGE_FILE      = "file"
+	STORAGE_ENCRYPTED = "encrypted"
+
+	SCOPE_SOURCE_PLAYGROUND = "playground"
+	SCOPE_SOURCE_FILE       = "file"
+	SCOPE_SOURCE_DISCOVERY  = "discovery"
+)
+
+type ScopeSourceConfig struct {
+	Type string   `yaml:"type"`
+	Path string   `yaml:"path,omitempty"`
+	URL  string   `yaml:"url,omitempty"`
+	APIs []string `yaml:"apis,omitempty"`
+}
+
 type Config struct {
 	Server struct {
 		DefaultPort   int           `yaml:"defaultPort"`
 		MaxPortTries  int           `yaml:"maxPortTries"`
 		ServerTimeout time.Duration `yaml:"serverTimeout"`
+		ListenIPv6    bool          `yaml:"listenIPv6"`
 	} `yaml:"server"`
 
 	OAuth struct {
+		Flow               string        `yaml:"flow"`
 		CallbackPath       string        `yaml:"callbackPath"`
+		DeviceAuthURL      string        `yaml:"deviceAuthURL"`
+		RevokeURL          string        `yaml:"revokeURL"`
+		UserInfoURL        string        `yaml:"userInfoURL"`
 		OAuthPlaygroundURL string        `yaml:"oauthPlaygroundURL"`
 		ScopeEndpoint      string        `yaml:"scopeEndpoint"`
 		ScopeTimeout       time.Duration `yaml:"scopeTimeout"`
+		ScopeCacheTTL      time.Duration `yaml:"scopeCacheTTL"`
 	} `yaml:"oauth"`
 
+	Scopes struct {
+		Sources     []ScopeSourceConfig `yaml:"sources"`
+		Sensitivity map[string]string   `yaml:"sensitivity"`
+	} `yaml:"scopes"`
+
+	Storage struct {
+		Backend   string `yaml:"backend"`
+		Directory string `yaml:"directory"`
+	} `yaml:"storage"`
+
+	Exec struct {
+		TokenVariables []string `yaml:"tokenVariables"`
+	} `yaml:"exec"`
+
+	Proxy struct {
+		Upstream string `yaml:"upstream"`
+		Port     int    `yaml:"port"`
+	} `yaml:"proxy"`
+
 	Terminal struct {
 		Height int `yaml:"height"`
 	} `yaml:"terminal"`
@@ -38,7 +83,7 @@ func LoadConfig(filename string) error {
 		return fmt.Errorf("error reading config file: %v", err)
 	}
 
-	config := &Config{}
+	config := GetDefaultConfig()
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return fmt.Errorf("error parsing config file: %v", err)
 	}
@@ -49,7 +94,7 @@ func LoadConfig(filename string) error {
 
 func LoadConfigWithDefaults(filename string) *Config {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		fmt.Printf("Config file '%s' not found, creating default configuration...\n", filename)
+		log.Printf("Config file '%s' not found, creating default configuration...\n", filename)
 		if err := CreateDefaultConfigFile(filename); err != nil {
 			log.Printf("Warning: Could not create config file (%v), using in-memory defaults\n", err)
 			config := GetDefaultConfig()
@@ -72,21 +117,59 @@ func GetDefaultConfig() *Config {
 			DefaultPort   int           `yaml:"defaultPort"`
 			MaxPortTries  int           `yaml:"maxPortTries"`
 			ServerTimeout time.Duration `yaml:"serverTimeout"`
+			ListenIPv6    bool          `yaml:"listenIPv6"`
 		}{
 			DefaultPort:   8080,
 			MaxPortTries:  10,
 			ServerTimeout: 5 * time.Minute,
+			ListenIPv6:    false,
 		},
 		OAuth: struct {
+			Flow               string        `yaml:"flow"`
 			CallbackPath       string        `yaml:"callbackPath"`
+			DeviceAuthURL      string        `yaml:"deviceAuthURL"`
+			RevokeURL          string        `yaml:"revokeURL"`
+			UserInfoURL        string        `yaml:"userInfoURL"`
 			OAuthPlaygroundURL string        `yaml:"oauthPlaygroundURL"`
 			ScopeEndpoint      string        `yaml:"scopeEndpoint"`
 			ScopeTimeout       time.Duration `yaml:"scopeTimeout"`
+			ScopeCacheTTL      time.Duration `yaml:"scopeCacheTTL"`
 		}{
+			Flow:               FLOW_LOOPBACK,
 			CallbackPath:       "/callback",
+			DeviceAuthURL:      "https://oauth2.googleapis.com/device/code",
+			RevokeURL:          "https://oauth2.googleapis.com/revoke",
+			UserInfoURL:        "https://openidconnect.googleapis.com/v1/userinfo",
 			OAuthPlaygroundURL: "https://developers.google.com/oauthplayground",
 			ScopeEndpoint:      "getScopes",
 			ScopeTimeout:       60 * time.Second,
+			ScopeCacheTTL:      24 * time.Hour,
+		},
+		Scopes: struct {
+			Sources     []ScopeSourceConfig `yaml:"sources"`
+			Sensitivity map[string]string   `yaml:"sensitivity"`
+		}{
+			Sources:     []ScopeSourceConfig{{Type: SCOPE_SOURCE_PLAYGROUND}},
+			Sensitivity: map[string]string{},
+		},
+		Storage: struct {
+			Backend   string `yaml:"backend"`
+			Directory string `yaml:"directory"`
+		}{
+			Backend:   STORAGE_FILE,
+			Directory: "",
+		},
+		Exec: struct {
+			TokenVariables []string `yaml:"tokenVariables"`
+		}{
+			TokenVariables: []string{"GOOGLE_OAUTH_ACCESS_TOKEN"},
+		},
+		Proxy: struct {
+			Upstream string `yaml:"upstream"`
+			Port     int    `yaml:"port"`
+		}{
+			Upstream: "https://www.googleapis.com",
+			Port:     8990,
 		},
 		Terminal: struct {
 			Height int `yaml:"height"`
@@ -108,7 +191,8 @@ func CreateDefaultConfigFile(filename string) error {
 # This file contains the configuration settings for the Google Auth Wizard application
 
 server:
-  # Default port for the OAuth callback server
+  # Default port for the OAuth callback server (0 lets the OS pick a free port)
+  # The server only listens on the loopback interface (127.0.0.1)
   defaultPort: 8080
   
   # Maximum number of ports to try if the default port is busy
@@ -116,11 +200,28 @@ server:
   
   # Timeout for the OAuth callback server (format: 5m, 300s, etc.)
   serverTimeout: 5m0s
+  
+  # Also listen on the IPv6 loopback address ([::1]) on the same port
+  listenIPv6: false
 
 oauth:
+  # Authorization flow: loopback (browser + local callback server), device (headless machines)
+  # or manual (paste the redirect URL back when the browser runs on another machine)
+  flow: loopback
+  
   # OAuth callback path
   callbackPath: /callback
   
+  # Google device authorization endpoint used by the device flow
+  deviceAuthURL: https://oauth2.googleapis.com/device/code
+  
+  # Google token revocation endpoint used by the revoke command
+  revokeURL: https://oauth2.googleapis.com/revoke
+  
+  # OpenID Connect userinfo endpoint used to record the account email
+  # when the token response has no id_token
+  userInfoURL: https://openidconnect.googleapis.com/v1/userinfo
+  
   # Google OAuth playground URL for fetching scopes
   oauthPlaygroundURL: https://developers.google.com/oauthplayground
   
@@ -129,6 +230,41 @@ oauth:
   
   # Timeout for scope fetching requests (format: 60s, 1m, etc.)
   scopeTimeout: 1m0s
+  
+  # How long the cached scope catalog is used without revalidation (0 always revalidates)
+  scopeCacheTTL: 24h0m0s
+
+scopes:
+  # Scope catalogs merged into the wizard, in order of precedence:
+  #   - type: playground (oauthPlaygroundURL above, or url)
+  #   - type: file with path to a YAML or JSON file of custom scopes
+  #   - type: discovery (Google API Discovery directory, or url), optionally limited to apis
+  sources:
+    - type: playground
+  
+  # Sensitivity overrides (non-sensitive, sensitive or restricted) applied on top of
+  # the built-in classification, e.g. https://www.googleapis.com/auth/drive.file: sensitive
+  sensitivity: {}
+
+storage:
+  # Token storage backend: file (plain JSON readable only by you)
+  # or encrypted (scrypt + AES-GCM, passphrase from GOOGLE_AUTH_WIZARD_PASSPHRASE or prompted)
+  backend: file
+  
+  # Directory holding the token profiles (empty uses ~/.google-auth-wizard)
+  directory: ""
+
+exec:
+  # Environment variables receiving the access token in the exec command
+  tokenVariables:
+    - GOOGLE_OAUTH_ACCESS_TOKEN
+
+proxy:
+  # Upstream the proxy command forwards requests to
+  upstream: https://www.googleapis.com
+  
+  # Loopback port the proxy command listens on
+  port: 8990
 
 terminal:
   # Terminal interface height (number of items to display)
@@ -149,8 +285,8 @@ func ConfigExists(filename string) bool {
 }
 
 func ValidateConfig(config *Config) error {
-	if config.Server.DefaultPort <= 0 || config.Server.DefaultPort > 65535 {
-		return fmt.Errorf("invalid default port: %d (must be between 1 and 65535)", config.Server.DefaultPort)
+	if config.Server.DefaultPort < 0 || config.Server.DefaultPort > 65535 {
+		return fmt.Errorf("invalid default port: %d (must be between 0 and 65535)", config.Server.DefaultPort)
 	}
 
 	if config.Server.MaxPortTries <= 0 {
@@ -165,10 +301,26 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("invalid scopeTimeout: %v (must be greater than 0)", config.OAuth.ScopeTimeout)
 	}
 
+	if config.OAuth.ScopeCacheTTL < 0 {
+		return fmt.Errorf("invalid scopeCacheTTL: %v (must not be negative)", config.OAuth.ScopeCacheTTL)
+	}
+
+	if !IsValidFlow(config.OAuth.Flow) {
+		return fmt.Errorf("invalid flow: %q (must be %s, %s or %s)", config.OAuth.Flow, FLOW_LOOPBACK, FLOW_DEVICE, FLOW_MANUAL)
+	}
+
 	if config.OAuth.CallbackPath == "" {
 		return fmt.Errorf("callbackPath cannot be empty")
 	}
 
+	if config.OAuth.Flow == FLOW_DEVICE && config.OAuth.DeviceAuthURL == "" {
+		return fmt.Errorf("deviceAuthURL cannot be empty when using the device flow")
+	}
+
+	if config.OAuth.RevokeURL == "" {
+		return fmt.Errorf("revokeURL cannot be empty")
+	}
+
 	if config.OAuth.OAuthPlaygroundURL == "" {
 		return fmt.Errorf("oauthPlaygroundURL cannot be empty")
 	}
@@ -177,9 +329,62 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("scopeEndpoint cannot be empty")
 	}
 
+	for i, source := range config.Scopes.Sources {
+		if !IsValidScopeSource(source.Type) {
+			return fmt.Errorf("invalid scope source #%d type: %q (must be %s, %s or %s)", i+1, source.Type, SCOPE_SOURCE_PLAYGROUND, SCOPE_SOURCE_FILE, SCOPE_SOURCE_DISCOVERY)
+		}
+
+		if source.Type == SCOPE_SOURCE_FILE && source.Path == "" {
+			return fmt.Errorf("scope source #%d: path cannot be empty for a file source", i+1)
+		}
+
+		if source.URL != "" && !strings.HasPrefix(source.URL, "http") {
+			return fmt.Errorf("invalid scope source #%d url: %q (must be an http or https URL)", i+1, source.URL)
+		}
+	}
+
+	if !strings.HasPrefix(config.Proxy.Upstream, "http") {
+		return fmt.Errorf("invalid proxy upstream: %q (must be an http or https URL)", config.Proxy.Upstream)
+	}
+
+	if config.Proxy.Port < 0 || config.Proxy.Port > 65535 {
+		return fmt.Errorf("invalid proxy port: %d (must be between 0 and 65535)", config.Proxy.Port)
+	}
+
+	if !IsValidStorageBackend(config.Storage.Backend) {
+		return fmt.Errorf("invalid storage backend: %q (must be %s or %s)", config.Storage.Backend, STORAGE_FILE, STORAGE_ENCRYPTED)
+	}
+
 	return nil
 }
 
+func IsValidFlow(flow string) bool {
+	switch flow {
+	case FLOW_LOOPBACK, FLOW_DEVICE, FLOW_MANUAL:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidStorageBackend(backend string) bool {
+	switch backend {
+	case STORAGE_FILE, STORAGE_ENCRYPTED:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidScopeSource(sourceType string) bool {
+	switch sourceType {
+	case SCOPE_SOURCE_PLAYGROUND, SCOPE_SOURCE_FILE, SCOPE_SOURCE_DISCOVERY:
+		return true
+	default:
+		return false
+	}
+}
+
 func LoadConfigWithValidation(filename string) (*Config, error) {
 	config := LoadConfigWithDefaults(filename)
 
@@ -192,7 +397,7 @@ func LoadConfigWithValidation(filename string) (*Config, error) {
 
 func applyEnvironmentOverrides(config *Config) *Config {
 	if port := os.Getenv("GOOGLE_AUTH_WIZARD_PORT"); port != "" {
-		if p, err := strconv.Atoi(port); err == nil && p > 0 && p <= 65535 {
+		if p, err := strconv.Atoi(port); err == nil && p >= 0 && p <= 65535 {
 			config.Server.DefaultPort = p
 		}
 	}
@@ -209,12 +414,36 @@ func applyEnvironmentOverrides(config *Config) *Config {
 		}
 	}
 
+	if listenIPv6 := os.Getenv("GOOGLE_AUTH_WIZARD_LISTEN_IPV6"); listenIPv6 != "" {
+		if b, err := strconv.ParseBool(listenIPv6); err == nil {
+			config.Server.ListenIPv6 = b
+		}
+	}
+
+	if flow := os.Getenv("GOOGLE_AUTH_WIZARD_FLOW"); flow != "" {
+		if IsValidFlow(flow) {
+			config.OAuth.Flow = flow
+		}
+	}
+
 	if callbackPath := os.Getenv("GOOGLE_AUTH_WIZARD_CALLBACK_PATH"); callbackPath != "" {
 		if strings.HasPrefix(callbackPath, "/") {
 			config.OAuth.CallbackPath = callbackPath
 		}
 	}
 
+	if revokeURL := os.Getenv("GOOGLE_AUTH_WIZARD_REVOKE_URL"); revokeURL != "" {
+		if strings.HasPrefix(revokeURL, "http") {
+			config.OAuth.RevokeURL = revokeURL
+		}
+	}
+
+	if userInfoURL := os.Getenv("GOOGLE_AUTH_WIZARD_USERINFO_URL"); userInfoURL != "" {
+		if strings.HasPrefix(userInfoURL, "http") {
+			config.OAuth.UserInfoURL = userInfoURL
+		}
+	}
+
 	if playgroundURL := os.Getenv("GOOGLE_AUTH_WIZARD_PLAYGROUND_URL"); playgroundURL != "" {
 		if strings.HasPrefix(playgroundURL, "http") {
 			config.OAuth.OAuthPlaygroundURL = playgroundURL
@@ -231,6 +460,38 @@ func applyEnvironmentOverrides(config *Config) *Config {
 		}
 	}
 
+	if scopeCacheTTL := os.Getenv("GOOGLE_AUTH_WIZARD_SCOPE_CACHE_TTL"); scopeCacheTTL != "" {
+		if t, err := time.ParseDuration(scopeCacheTTL); err == nil && t >= 0 {
+			config.OAuth.ScopeCacheTTL = t
+		}
+	}
+
+	if backend := os.Getenv("GOOGLE_AUTH_WIZARD_STORAGE_BACKEND"); backend != "" {
+		if IsValidStorageBackend(backend) {
+			config.Storage.Backend = backend
+		}
+	}
+
+	if directory := os.Getenv("GOOGLE_AUTH_WIZARD_STORAGE_DIR"); directory != "" {
+		config.Storage.Directory = directory
+	}
+
+	if tokenVariables := os.Getenv("GOOGLE_AUTH_WIZARD_EXEC_TOKEN_VARS"); tokenVariables != "" {
+		config.Exec.TokenVariables = strings.Split(tokenVariables, ",")
+	}
+
+	if upstream := os.Getenv("GOOGLE_AUTH_WIZARD_PROXY_UPSTREAM"); upstream != "" {
+		if strings.HasPrefix(upstream, "http") {
+			config.Proxy.Upstream = upstream
+		}
+	}
+
+	if port := os.Getenv("GOOGLE_AUTH_WIZARD_PROXY_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil && p >= 0 && p <= 65535 {
+			config.Proxy.Port = p
+		}
+	}
+
 	if height := os.Getenv("GOOGLE_AUTH_WIZARD_TERMINAL_HEIGHT"); height != "" {
 		if h, err := strconv.Atoi(height); err == nil && h > 0 {
 			config.Terminal.Height = h
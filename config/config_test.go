@@ -23,6 +23,10 @@ oauth:
   scopeEndpoint: testScopes
   scopeTimeout: 30s
 
+scopes:
+  sensitivity:
+    https://www.googleapis.com/auth/drive.file: sensitive
+
 terminal:
   height: 15
 `
@@ -53,6 +57,10 @@ terminal:
 	if cfg.Terminal.Height != 15 {
 		t.Errorf("Expected Terminal Height 15, got %d", cfg.Terminal.Height)
 	}
+
+	if level := cfg.Scopes.Sensitivity["https://www.googleapis.com/auth/drive.file"]; level != "sensitive" {
+		t.Errorf("Expected the drive.file sensitivity override, got %q", level)
+	}
 }
 
 func TestLoadConfigWithDefaults_FileNotExists(t *testing.T) {
@@ -69,6 +77,119 @@ func TestLoadConfigWithDefaults_FileNotExists(t *testing.T) {
 	if cfg.OAuth.CallbackPath != defaultCfg.OAuth.CallbackPath {
 		t.Errorf("Expected default CallbackPath %s, got %s", defaultCfg.OAuth.CallbackPath, cfg.OAuth.CallbackPath)
 	}
+
+	if len(cfg.Scopes.Sources) != 1 || cfg.Scopes.Sources[0].Type != SCOPE_SOURCE_PLAYGROUND {
+		t.Errorf("Expected the playground as default scope source, got %+v", cfg.Scopes.Sources)
+	}
+}
+
+func TestLoadConfigWithDefaults_LegacyFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+
+	legacyContent := `# Google Auth Wizard Configuration
+# This file contains the configuration settings for the Google Auth Wizard application
+
+server:
+  # Default port for the OAuth callback server
+  defaultPort: 8080
+  
+  # Maximum number of ports to try if the default port is busy
+  maxPortTries: 10
+  
+  # Timeout for the OAuth callback server (format: 5m, 300s, etc.)
+  serverTimeout: 5m0s
+
+oauth:
+  # OAuth callback path
+  callbackPath: /callback
+  
+  # Google OAuth playground URL for fetching scopes
+  oauthPlaygroundURL: https://developers.google.com/oauthplayground
+  
+  # Endpoint for fetching scopes
+  scopeEndpoint: getScopes
+  
+  # Timeout for scope fetching requests (format: 60s, 1m, etc.)
+  scopeTimeout: 1m0s
+
+terminal:
+  # Terminal interface height (number of items to display)
+  height: 20
+`
+
+	if err := os.WriteFile(configFile, []byte(legacyContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	cfg := LoadConfigWithDefaults(configFile)
+	defaultCfg := GetDefaultConfig()
+
+	if err := ValidateConfig(cfg); err != nil {
+		t.Errorf("Expected a legacy config to be valid, got: %v", err)
+	}
+
+	if cfg.OAuth.Flow != FLOW_LOOPBACK {
+		t.Errorf("Expected Flow %q, got %q", FLOW_LOOPBACK, cfg.OAuth.Flow)
+	}
+
+	if cfg.OAuth.DeviceAuthURL != defaultCfg.OAuth.DeviceAuthURL {
+		t.Errorf("Expected default DeviceAuthURL, got %q", cfg.OAuth.DeviceAuthURL)
+	}
+
+	if cfg.OAuth.RevokeURL != defaultCfg.OAuth.RevokeURL {
+		t.Errorf("Expected default RevokeURL, got %q", cfg.OAuth.RevokeURL)
+	}
+
+	if cfg.OAuth.UserInfoURL != defaultCfg.OAuth.UserInfoURL {
+		t.Errorf("Expected default UserInfoURL, got %q", cfg.OAuth.UserInfoURL)
+	}
+
+	if cfg.OAuth.ScopeCacheTTL != defaultCfg.OAuth.ScopeCacheTTL {
+		t.Errorf("Expected default ScopeCacheTTL, got %v", cfg.OAuth.ScopeCacheTTL)
+	}
+
+	if len(cfg.Scopes.Sources) != 1 || cfg.Scopes.Sources[0].Type != SCOPE_SOURCE_PLAYGROUND {
+		t.Errorf("Expected the playground as default scope source, got %+v", cfg.Scopes.Sources)
+	}
+
+	if cfg.Storage.Backend != STORAGE_FILE {
+		t.Errorf("Expected Backend %q, got %q", STORAGE_FILE, cfg.Storage.Backend)
+	}
+
+	if len(cfg.Exec.TokenVariables) != 1 || cfg.Exec.TokenVariables[0] != "GOOGLE_OAUTH_ACCESS_TOKEN" {
+		t.Errorf("Expected the default token variable, got %v", cfg.Exec.TokenVariables)
+	}
+
+	if cfg.Proxy.Upstream != defaultCfg.Proxy.Upstream || cfg.Proxy.Port != defaultCfg.Proxy.Port {
+		t.Errorf("Expected the default proxy settings, got %+v", cfg.Proxy)
+	}
+}
+
+func TestLoadConfigWithDefaults_ReplacesLists(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+
+	configContent := `
+scopes:
+  sources:
+    - type: file
+      path: scopes.yaml
+exec:
+  tokenVariables: [TOKEN]
+`
+
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	cfg := LoadConfigWithDefaults(configFile)
+
+	if len(cfg.Scopes.Sources) != 1 || cfg.Scopes.Sources[0].Type != SCOPE_SOURCE_FILE {
+		t.Errorf("Expected the configured sources to replace the defaults, got %+v", cfg.Scopes.Sources)
+	}
+
+	if len(cfg.Exec.TokenVariables) != 1 || cfg.Exec.TokenVariables[0] != "TOKEN" {
+		t.Errorf("Expected the configured token variables to replace the defaults, got %v", cfg.Exec.TokenVariables)
+	}
 }
 
 func TestValidateConfig_Valid(t *testing.T) {
@@ -94,6 +215,15 @@ func TestValidateConfig_InvalidPort(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_OSAssignedPort(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Server.DefaultPort = 0
+
+	if err := ValidateConfig(cfg); err != nil {
+		t.Errorf("Port 0 should be accepted for an OS-assigned port, got: %v", err)
+	}
+}
+
 func TestValidateConfig_InvalidMaxPortTries(t *testing.T) {
 	cfg := GetDefaultConfig()
 	cfg.Server.MaxPortTries = 0
@@ -114,6 +244,65 @@ func TestValidateConfig_EmptyCallbackPath(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_InvalidFlow(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.OAuth.Flow = "carrier-pigeon"
+
+	err := ValidateConfig(cfg)
+	if err == nil {
+		t.Error("Expected error for invalid flow, got nil")
+	}
+
+	cfg.OAuth.Flow = FLOW_DEVICE
+	cfg.OAuth.DeviceAuthURL = ""
+
+	err = ValidateConfig(cfg)
+	if err == nil {
+		t.Error("Expected error for device flow without deviceAuthURL, got nil")
+	}
+}
+
+func TestValidateConfig_InvalidProxyUpstream(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Proxy.Upstream = "www.googleapis.com"
+
+	if err := ValidateConfig(cfg); err == nil {
+		t.Error("Expected validation error for a proxy upstream without scheme")
+	}
+}
+
+func TestValidateConfig_InvalidScopeSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		source ScopeSourceConfig
+	}{
+		{"unknown type", ScopeSourceConfig{Type: "github"}},
+		{"file without path", ScopeSourceConfig{Type: SCOPE_SOURCE_FILE}},
+		{"url without scheme", ScopeSourceConfig{Type: SCOPE_SOURCE_DISCOVERY, URL: "www.googleapis.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.Scopes.Sources = append(cfg.Scopes.Sources, tt.source)
+
+			if err := ValidateConfig(cfg); err == nil {
+				t.Errorf("Expected validation error for scope source %+v", tt.source)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_InvalidStorageBackend(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Storage.Backend = "keyring"
+
+	err := ValidateConfig(cfg)
+	if err == nil {
+		t.Error("Expected error for invalid storage backend, got nil")
+	}
+}
+
 func TestConfigExists(t *testing.T) {
 	tempDir := t.TempDir()
 	configFile := filepath.Join(tempDir, "existing_config.yaml")
@@ -150,10 +339,20 @@ func TestApplyEnvironmentOverrides(t *testing.T) {
 		"GOOGLE_AUTH_WIZARD_PORT":            os.Getenv("GOOGLE_AUTH_WIZARD_PORT"),
 		"GOOGLE_AUTH_WIZARD_MAX_PORT_TRIES":  os.Getenv("GOOGLE_AUTH_WIZARD_MAX_PORT_TRIES"),
 		"GOOGLE_AUTH_WIZARD_SERVER_TIMEOUT":  os.Getenv("GOOGLE_AUTH_WIZARD_SERVER_TIMEOUT"),
+		"GOOGLE_AUTH_WIZARD_LISTEN_IPV6":     os.Getenv("GOOGLE_AUTH_WIZARD_LISTEN_IPV6"),
+		"GOOGLE_AUTH_WIZARD_FLOW":            os.Getenv("GOOGLE_AUTH_WIZARD_FLOW"),
 		"GOOGLE_AUTH_WIZARD_CALLBACK_PATH":   os.Getenv("GOOGLE_AUTH_WIZARD_CALLBACK_PATH"),
+		"GOOGLE_AUTH_WIZARD_REVOKE_URL":      os.Getenv("GOOGLE_AUTH_WIZARD_REVOKE_URL"),
+		"GOOGLE_AUTH_WIZARD_USERINFO_URL":    os.Getenv("GOOGLE_AUTH_WIZARD_USERINFO_URL"),
 		"GOOGLE_AUTH_WIZARD_PLAYGROUND_URL":  os.Getenv("GOOGLE_AUTH_WIZARD_PLAYGROUND_URL"),
 		"GOOGLE_AUTH_WIZARD_SCOPE_ENDPOINT":  os.Getenv("GOOGLE_AUTH_WIZARD_SCOPE_ENDPOINT"),
 		"GOOGLE_AUTH_WIZARD_SCOPE_TIMEOUT":   os.Getenv("GOOGLE_AUTH_WIZARD_SCOPE_TIMEOUT"),
+		"GOOGLE_AUTH_WIZARD_SCOPE_CACHE_TTL": os.Getenv("GOOGLE_AUTH_WIZARD_SCOPE_CACHE_TTL"),
+		"GOOGLE_AUTH_WIZARD_STORAGE_BACKEND": os.Getenv("GOOGLE_AUTH_WIZARD_STORAGE_BACKEND"),
+		"GOOGLE_AUTH_WIZARD_STORAGE_DIR":     os.Getenv("GOOGLE_AUTH_WIZARD_STORAGE_DIR"),
+		"GOOGLE_AUTH_WIZARD_EXEC_TOKEN_VARS": os.Getenv("GOOGLE_AUTH_WIZARD_EXEC_TOKEN_VARS"),
+		"GOOGLE_AUTH_WIZARD_PROXY_UPSTREAM":  os.Getenv("GOOGLE_AUTH_WIZARD_PROXY_UPSTREAM"),
+		"GOOGLE_AUTH_WIZARD_PROXY_PORT":      os.Getenv("GOOGLE_AUTH_WIZARD_PROXY_PORT"),
 		"GOOGLE_AUTH_WIZARD_TERMINAL_HEIGHT": os.Getenv("GOOGLE_AUTH_WIZARD_TERMINAL_HEIGHT"),
 	}
 
@@ -170,10 +369,20 @@ func TestApplyEnvironmentOverrides(t *testing.T) {
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_PORT", "9090")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_MAX_PORT_TRIES", "15")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_SERVER_TIMEOUT", "10m")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_LISTEN_IPV6", "true")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_FLOW", "device")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_CALLBACK_PATH", "/custom-callback")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_REVOKE_URL", "http://127.0.0.1:9999/revoke")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_USERINFO_URL", "http://127.0.0.1:9999/userinfo")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_PLAYGROUND_URL", "https://custom.example.com")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_SCOPE_ENDPOINT", "customScopes")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_SCOPE_TIMEOUT", "2m")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_SCOPE_CACHE_TTL", "6h")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_STORAGE_BACKEND", "encrypted")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_STORAGE_DIR", "/tmp/tokens")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_EXEC_TOKEN_VARS", "ACCESS_TOKEN,CLOUDSDK_AUTH_ACCESS_TOKEN")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_PROXY_UPSTREAM", "https://storage.googleapis.com")
+	_ = os.Setenv("GOOGLE_AUTH_WIZARD_PROXY_PORT", "9991")
 	_ = os.Setenv("GOOGLE_AUTH_WIZARD_TERMINAL_HEIGHT", "25")
 
 	config := GetDefaultConfig()
@@ -192,10 +401,26 @@ func TestApplyEnvironmentOverrides(t *testing.T) {
 		t.Errorf("Expected ServerTimeout 10m, got %v", config.Server.ServerTimeout)
 	}
 
+	if !config.Server.ListenIPv6 {
+		t.Error("Expected ListenIPv6 to be enabled")
+	}
+
+	if config.OAuth.Flow != FLOW_DEVICE {
+		t.Errorf("Expected Flow 'device', got %s", config.OAuth.Flow)
+	}
+
 	if config.OAuth.CallbackPath != "/custom-callback" {
 		t.Errorf("Expected CallbackPath '/custom-callback', got %s", config.OAuth.CallbackPath)
 	}
 
+	if config.OAuth.RevokeURL != "http://127.0.0.1:9999/revoke" {
+		t.Errorf("Expected RevokeURL 'http://127.0.0.1:9999/revoke', got %s", config.OAuth.RevokeURL)
+	}
+
+	if config.OAuth.UserInfoURL != "http://127.0.0.1:9999/userinfo" {
+		t.Errorf("Expected UserInfoURL 'http://127.0.0.1:9999/userinfo', got %s", config.OAuth.UserInfoURL)
+	}
+
 	if config.OAuth.OAuthPlaygroundURL != "https://custom.example.com" {
 		t.Errorf("Expected OAuthPlaygroundURL 'https://custom.example.com', got %s", config.OAuth.OAuthPlaygroundURL)
 	}
@@ -208,6 +433,30 @@ func TestApplyEnvironmentOverrides(t *testing.T) {
 		t.Errorf("Expected ScopeTimeout 2m, got %v", config.OAuth.ScopeTimeout)
 	}
 
+	if config.OAuth.ScopeCacheTTL != 6*time.Hour {
+		t.Errorf("Expected ScopeCacheTTL 6h, got %v", config.OAuth.ScopeCacheTTL)
+	}
+
+	if config.Storage.Backend != STORAGE_ENCRYPTED {
+		t.Errorf("Expected Storage Backend 'encrypted', got %s", config.Storage.Backend)
+	}
+
+	if config.Storage.Directory != "/tmp/tokens" {
+		t.Errorf("Expected Storage Directory '/tmp/tokens', got %s", config.Storage.Directory)
+	}
+
+	if len(config.Exec.TokenVariables) != 2 || config.Exec.TokenVariables[1] != "CLOUDSDK_AUTH_ACCESS_TOKEN" {
+		t.Errorf("Expected Exec TokenVariables [ACCESS_TOKEN CLOUDSDK_AUTH_ACCESS_TOKEN], got %v", config.Exec.TokenVariables)
+	}
+
+	if config.Proxy.Upstream != "https://storage.googleapis.com" {
+		t.Errorf("Expected Proxy Upstream 'https://storage.googleapis.com', got %s", config.Proxy.Upstream)
+	}
+
+	if config.Proxy.Port != 9991 {
+		t.Errorf("Expected Proxy Port 9991, got %d", config.Proxy.Port)
+	}
+
 	if config.Terminal.Height != 25 {
 		t.Errorf("Expected Terminal Height 25, got %d", config.Terminal.Height)
 	}
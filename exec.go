@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/logger"
+	"google-auth-wizard/utils"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("command exited with status %d", e.code)
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+func runExec(args []string) error {
+	var variables []string
+
+	flagSet := flag.NewFlagSet("exec", flag.ContinueOnError)
+	filename := flagSet.String("file", "", "Path to the client secret JSON file, needed to refresh an expired token")
+	flagSet.StringVar(filename, "f", "", "Path to the client secret JSON file (shortcut)")
+	profile := flagSet.String("profile", "", "Token profile to use (defaults to the current profile)")
+	flagSet.Func("var", "Environment variable receiving the access token (repeatable, overrides exec.tokenVariables)", func(value string) error {
+		variables = append(variables, value)
+		return nil
+	})
+	flagSet.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  google-auth-wizard exec [-profile <name>] [-f <credentials.json>] [-var <NAME>] -- <command> [args...]")
+		fmt.Println("\nOptions:")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if flagSet.NArg() == 0 {
+		flagSet.Usage()
+		return fmt.Errorf("exec requires a command to run")
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+	if len(variables) == 0 {
+		variables = cfg.Exec.TokenVariables
+	}
+	if len(variables) == 0 {
+		return fmt.Errorf("exec requires at least one token variable (-var or exec.tokenVariables)")
+	}
+	for _, variable := range variables {
+		if !envVarNamePattern.MatchString(variable) {
+			return fmt.Errorf("invalid variable name %q", variable)
+		}
+	}
+
+	tokenStorage, name, err := openProfile(cfg, *profile)
+	if err != nil {
+		return err
+	}
+
+	var credentials []byte
+	if *filename != "" {
+		credentials = utils.ReadCredentials(*filename)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	token, err := newProfileTokenSource(ctx, tokenStorage, name, credentials).Token()
+	stop()
+	if err != nil {
+		return err
+	}
+
+	env := os.Environ()
+	for _, variable := range variables {
+		env = append(env, variable+"="+token.AccessToken)
+	}
+
+	cmd := exec.Command(flagSet.Arg(0), flagSet.Args()[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	logger.Debug("Running %q with the token of profile %q in %v", flagSet.Arg(0), name, variables)
+	return runForwardingSignals(cmd)
+}
+
+func runForwardingSignals(cmd *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	foreground := sharesForegroundProcessGroup()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if shouldForward(sig, foreground) {
+					_ = cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &exitCodeError{code: exitCode(exitErr.ProcessState)}
+	}
+	return err
+}
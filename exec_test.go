@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"google-auth-wizard/storage"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	helperProcessEnv      = "GOOGLE_AUTH_WIZARD_HELPER_PROCESS"
+	helperExpectedVarsEnv = "GOOGLE_AUTH_WIZARD_HELPER_EXPECTED_VARS"
+)
+
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperProcessEnv)
+	if mode == "" {
+		return
+	}
+
+	switch mode {
+	case "env":
+		for _, variable := range strings.Split(os.Getenv(helperExpectedVarsEnv), ",") {
+			if os.Getenv(variable) != "access-token" {
+				fmt.Fprintf(os.Stderr, "%s=%q\n", variable, os.Getenv(variable))
+				os.Exit(4)
+			}
+		}
+		os.Exit(0)
+	case "wait":
+		fmt.Println("ready")
+		time.Sleep(30 * time.Second)
+		os.Exit(0)
+	default:
+		code, _ := strconv.Atoi(mode)
+		os.Exit(code)
+	}
+}
+
+func helperCommand(mode string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), helperProcessEnv+"="+mode)
+	return cmd
+}
+
+func TestRunForwardingSignals_ExitCode(t *testing.T) {
+	err := runForwardingSignals(helperCommand("3"))
+
+	var exitErr *exitCodeError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected an exitCodeError, got %v", err)
+	}
+	if exitErr.code != 3 {
+		t.Errorf("Expected exit code 3, got %d", exitErr.code)
+	}
+}
+
+func TestRunForwardingSignals_Success(t *testing.T) {
+	if err := runForwardingSignals(helperCommand("0")); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestRunForwardingSignals_StartFailure(t *testing.T) {
+	err := runForwardingSignals(exec.Command("google-auth-wizard-missing-command"))
+
+	var exitErr *exitCodeError
+	if err == nil || errors.As(err, &exitErr) {
+		t.Errorf("Expected a start error, got %v", err)
+	}
+}
+
+func TestRunExec_InjectsTokenVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		configVar string
+		expected  string
+	}{
+		{"default variable", nil, "", "GOOGLE_OAUTH_ACCESS_TOKEN"},
+		{"configured variables", nil, "CLOUDSDK_AUTH_ACCESS_TOKEN,ACCESS_TOKEN", "CLOUDSDK_AUTH_ACCESS_TOKEN,ACCESS_TOKEN"},
+		{"flag overrides config", []string{"-var", "FIRST", "-var", "SECOND"}, "ACCESS_TOKEN", "FIRST,SECOND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestProfile(t, storage.Owner{ClientID: "client-id"})
+			t.Setenv("GOOGLE_AUTH_WIZARD_EXEC_TOKEN_VARS", tt.configVar)
+			t.Setenv(helperProcessEnv, "env")
+			t.Setenv(helperExpectedVarsEnv, tt.expected)
+
+			args := append(tt.args, "--", os.Args[0], "-test.run=^TestHelperProcess$")
+			if err := runExec(args); err != nil {
+				t.Errorf("Expected the child to see the token in %s, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestRunExec_InvalidVariable(t *testing.T) {
+	setupTestProfile(t, storage.Owner{ClientID: "client-id"})
+
+	if err := runExec([]string{"-var", "NOT-VALID", "--", "true"}); err == nil {
+		t.Error("Expected an error for an invalid variable name")
+	}
+}
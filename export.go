@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"google-auth-wizard/auth"
+	"google-auth-wizard/config"
+	"google-auth-wizard/storage"
+	"google-auth-wizard/utils"
+	"os"
+	"path/filepath"
+)
+
+func runExportADC(args []string) error {
+	flagSet := flag.NewFlagSet("export-adc", flag.ContinueOnError)
+	filename := flagSet.String("file", "", "Path to the client secret JSON file the token was obtained with")
+	flagSet.StringVar(filename, "f", "", "Path to the client secret JSON file (shortcut)")
+	profile := flagSet.String("profile", "", "Token profile to export (defaults to the current profile)")
+	output := flagSet.String("output", "", "Destination file (defaults to the gcloud Application Default Credentials location)")
+	flagSet.StringVar(output, "o", "", "Destination file (shortcut)")
+	quotaProject := flagSet.String("quota-project", "", "Quota project ID (defaults to the project of the credentials file)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if *filename == "" {
+		flagSet.Usage()
+		return fmt.Errorf("export-adc requires the client secret file (-f)")
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+	tokenStorage, name, err := openProfile(cfg, *profile)
+	if err != nil {
+		return err
+	}
+
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load profile %q: %w", name, err)
+	}
+
+	credentials := utils.ReadCredentials(*filename)
+	oauthConfig, err := auth.CreateOAuthConfig(credentials, storedToken.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth config: %w", err)
+	}
+
+	if !storedToken.BelongsTo(oauthConfig.ClientID) {
+		return fmt.Errorf("profile %q was not obtained with client %s from %s", name, oauthConfig.ClientID, *filename)
+	}
+
+	quotaProjectID := *quotaProject
+	if quotaProjectID == "" {
+		quotaProjectID = storedToken.ProjectID
+	}
+
+	adc, err := auth.NewAuthorizedUserCredentials(oauthConfig, storedToken.Token, quotaProjectID, storedToken.Email)
+	if err != nil {
+		return fmt.Errorf("failed to export profile %q: %w", name, err)
+	}
+
+	path := *output
+	if path == "" {
+		if path, err = auth.DefaultADCPath(); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(adc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	if err := storage.WriteFileAtomic(path, data, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Application Default Credentials for profile %q (%d scopes) written to %s\n", name, len(storedToken.Scopes), path)
+	if *output != "" {
+		fmt.Printf("Point Google client libraries at it with: export GOOGLE_APPLICATION_CREDENTIALS=%s\n", path)
+	}
+	return nil
+}
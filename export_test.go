@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"google-auth-wizard/auth"
+	"google-auth-wizard/config"
+	"google-auth-wizard/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+const exportTestCredentials = `{
+	"installed": {
+		"client_id": "%s",
+		"project_id": "credentials-project",
+		"auth_uri": "https://accounts.google.com/o/oauth2/auth",
+		"token_uri": "https://oauth2.googleapis.com/token",
+		"client_secret": "client-secret",
+		"redirect_uris": ["http://localhost"]
+	}
+}`
+
+func setupTestProfile(t *testing.T, owner storage.Owner) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GOOGLE_AUTH_WIZARD_STORAGE_DIR", filepath.Join(dir, "storage"))
+	t.Setenv("CLOUDSDK_CONFIG", filepath.Join(dir, "gcloud"))
+
+	tokenStorage, _, err := openProfile(config.LoadConfigWithDefaults("config.yaml"), "")
+	if err != nil {
+		t.Fatalf("Failed to open profile: %v", err)
+	}
+
+	token := &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"}
+	if err := tokenStorage.Save(token, []string{"https://www.googleapis.com/auth/drive"}, owner); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+	return dir
+}
+
+func writeExportCredentials(t *testing.T, dir, clientID string) string {
+	t.Helper()
+	path := filepath.Join(dir, "client_secret.json")
+	content := fmt.Sprintf(exportTestCredentials, clientID)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write credentials: %v", err)
+	}
+	return path
+}
+
+func readExportedADC(t *testing.T, path string) *auth.AuthorizedUserCredentials {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected credentials at %s, got %v", path, err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read credentials: %v", err)
+	}
+
+	var adc auth.AuthorizedUserCredentials
+	if err := json.Unmarshal(data, &adc); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	return &adc
+}
+
+func TestRunExportADC_DefaultPath(t *testing.T) {
+	dir := setupTestProfile(t, storage.Owner{ClientID: "client-id", ProjectID: "token-project", Email: "user@example.com"})
+	credentials := writeExportCredentials(t, dir, "client-id")
+
+	if err := runExportADC([]string{"-f", credentials}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	adc := readExportedADC(t, filepath.Join(dir, "gcloud", auth.ADC_FILE_NAME))
+	if adc.Type != auth.AUTHORIZED_USER_TYPE || adc.ClientID != "client-id" || adc.ClientSecret != "client-secret" {
+		t.Errorf("Unexpected credentials: %+v", adc)
+	}
+	if adc.RefreshToken != "refresh-token" {
+		t.Errorf("Expected the stored refresh token, got %q", adc.RefreshToken)
+	}
+	if adc.QuotaProjectID != "token-project" {
+		t.Errorf("Expected the token project as quota project, got %q", adc.QuotaProjectID)
+	}
+	if adc.Account != "user@example.com" {
+		t.Errorf("Expected the account email, got %q", adc.Account)
+	}
+}
+
+func TestRunExportADC_OutputAndQuotaProject(t *testing.T) {
+	dir := setupTestProfile(t, storage.Owner{ClientID: "client-id", ProjectID: "token-project"})
+	credentials := writeExportCredentials(t, dir, "client-id")
+	output := filepath.Join(dir, "nested", "adc.json")
+
+	if err := runExportADC([]string{"-f", credentials, "-o", output, "-quota-project", "billing-project"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	adc := readExportedADC(t, output)
+	if adc.QuotaProjectID != "billing-project" {
+		t.Errorf("Expected the -quota-project value, got %q", adc.QuotaProjectID)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gcloud", auth.ADC_FILE_NAME)); !os.IsNotExist(err) {
+		t.Error("Expected the default location to be left untouched")
+	}
+}
+
+func TestRunExportADC_ClientMismatch(t *testing.T) {
+	dir := setupTestProfile(t, storage.Owner{ClientID: "client-id"})
+	credentials := writeExportCredentials(t, dir, "other-client-id")
+	output := filepath.Join(dir, "adc.json")
+
+	err := runExportADC([]string{"-f", credentials, "-o", output})
+	if err == nil || !strings.Contains(err.Error(), "was not obtained with client other-client-id") {
+		t.Fatalf("Expected a client mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Error("Expected no credentials to be written on a client mismatch")
+	}
+}
+
+func TestRunExportADC_RequiresCredentialsFile(t *testing.T) {
+	if err := runExportADC(nil); err == nil {
+		t.Error("Expected an error without -f")
+	}
+}
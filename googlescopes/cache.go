@@ -0,0 +1,109 @@
+package googlescopes
+
+import (
+	"encoding/json"
+	"fmt"
+	"google-auth-wizard/storage"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const SCOPE_CACHE_FILE = "scopes_cache.json"
+
+type sourceEntry struct {
+	FetchedAt    time.Time      `json:"fetched_at"`
+	ETag         string         `json:"etag,omitempty"`
+	LastModified string         `json:"last_modified,omitempty"`
+	Services     GoogleServices `json:"services"`
+}
+
+type cacheEntry struct {
+	Sources map[string]*sourceEntry `json:"sources"`
+}
+
+func (e *cacheEntry) covers(sources []ScopeSource) bool {
+	for _, source := range sources {
+		if e.Sources[source.Name()] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *cacheEntry) isFresh(sources []ScopeSource, ttl time.Duration) bool {
+	if ttl <= 0 || !e.covers(sources) {
+		return false
+	}
+
+	for _, source := range sources {
+		if time.Since(e.Sources[source.Name()].FetchedAt) >= ttl {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *cacheEntry) catalog(sources []ScopeSource, origin string) *Catalog {
+	all := make([]*GoogleServices, 0, len(sources))
+	var fetchedAt time.Time
+
+	for _, source := range sources {
+		entry := e.Sources[source.Name()]
+		if entry == nil {
+			continue
+		}
+
+		all = append(all, &entry.Services)
+		if fetchedAt.IsZero() || entry.FetchedAt.Before(fetchedAt) {
+			fetchedAt = entry.FetchedAt
+		}
+	}
+
+	return &Catalog{Services: MergeServices(all...), Origin: origin, FetchedAt: fetchedAt}
+}
+
+func (e *cacheEntry) withoutLocal(sources []ScopeSource) *cacheEntry {
+	remote := &cacheEntry{Sources: make(map[string]*sourceEntry, len(e.Sources))}
+	for name, entry := range e.Sources {
+		remote.Sources[name] = entry
+	}
+
+	for _, source := range sources {
+		if isLocalSource(source) {
+			delete(remote.Sources, source.Name())
+		}
+	}
+	return remote
+}
+
+func loadCache(path string) (*cacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("error parsing scope cache %s: %w", path, err)
+	}
+
+	if entry.Sources == nil {
+		return nil, fmt.Errorf("scope cache %s holds no sources", path)
+	}
+
+	return &entry, nil
+}
+
+func saveCache(path string, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshaling scope cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating scope cache directory: %w", err)
+	}
+
+	return storage.WriteFileAtomic(path, data, 0600)
+}
@@ -0,0 +1,236 @@
+package googlescopes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testETag = `"catalog-v1"`
+
+type fakePlayground struct {
+	*httptest.Server
+	mu            sync.Mutex
+	requests      int
+	revalidations int
+	down          bool
+}
+
+func newFakePlayground(t *testing.T) *fakePlayground {
+	f := &fakePlayground{}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.requests++
+		if f.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == testETag {
+			f.revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", testETag)
+		w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 00:00:00 GMT")
+		_ = json.NewEncoder(w).Encode(getScopesResponse{
+			Success: true,
+			Apis: map[string]apiInfoResponse{
+				"Drive API": {Scopes: []map[string]scopeResponse{
+					{"https://www.googleapis.com/auth/drive": {Description: "Full access to Drive"}},
+				}},
+			},
+		})
+	}))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakePlayground) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests, f.revalidations
+}
+
+func (f *fakePlayground) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func fetchCatalog(t *testing.T, options ...ClientOption) *Catalog {
+	t.Helper()
+
+	catalog, err := NewClient(options...).FetchCatalog(t.Context())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !catalog.Services.HasService("Drive API") {
+		t.Errorf("Expected the Drive API in the catalog, got %v", catalog.Services.GetAllServiceNames())
+	}
+	return catalog
+}
+
+func TestFetchCatalog_FreshCacheSkipsNetwork(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	if catalog := fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour)); catalog.Origin != ORIGIN_NETWORK {
+		t.Errorf("Expected the first fetch to come from the network, got %s", catalog.Origin)
+	}
+
+	if catalog := fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour)); catalog.Origin != ORIGIN_CACHE {
+		t.Errorf("Expected the second fetch to come from the cache, got %s", catalog.Origin)
+	}
+
+	if requests, _ := fake.counts(); requests != 1 {
+		t.Errorf("Expected a single network request, got %d", requests)
+	}
+}
+
+func TestFetchCatalog_ZeroTTLRevalidates(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, 0))
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, 0))
+
+	if requests, _ := fake.counts(); requests != 2 {
+		t.Errorf("Expected a zero TTL to revalidate on every fetch, got %d requests", requests)
+	}
+}
+
+func TestFetchCatalog_ExpiredCacheRevalidates(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+
+	cached, err := loadCache(cachePath)
+	if err != nil {
+		t.Fatalf("Expected a cache file, got %v", err)
+	}
+	entry := cached.Sources["playground:"+fake.URL]
+	if entry == nil {
+		t.Fatalf("Expected the playground source to be cached, got %v", cached.Sources)
+	}
+	if entry.ETag != testETag || entry.LastModified == "" {
+		t.Errorf("Expected validators to be cached, got %q / %q", entry.ETag, entry.LastModified)
+	}
+	entry.FetchedAt = time.Now().Add(-2 * time.Hour)
+	if err := saveCache(cachePath, cached); err != nil {
+		t.Fatalf("Failed to age the cache: %v", err)
+	}
+
+	catalog := fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+	if catalog.Origin != ORIGIN_CACHE {
+		t.Errorf("Expected a revalidated cache, got %s", catalog.Origin)
+	}
+	if time.Since(catalog.FetchedAt) > time.Minute {
+		t.Errorf("Expected the revalidation to renew the cache date, got %v", catalog.FetchedAt)
+	}
+
+	if requests, revalidations := fake.counts(); requests != 2 || revalidations != 1 {
+		t.Errorf("Expected one conditional request, got %d requests and %d revalidations", requests, revalidations)
+	}
+}
+
+func TestFetchCatalog_ForceRefreshIgnoresTTL(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour), WithForceRefresh(true))
+
+	if requests, _ := fake.counts(); requests != 2 {
+		t.Errorf("Expected the forced refresh to hit the network, got %d requests", requests)
+	}
+}
+
+func TestFetchCatalog_ReadOnlyCacheKeepsFile(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+	before, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("Expected a cache file, got %v", err)
+	}
+
+	catalog := fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour), WithForceRefresh(true), WithReadOnlyCache(true))
+	if time.Since(catalog.FetchedAt) > time.Minute {
+		t.Errorf("Expected a freshly revalidated catalog, got %v", catalog.FetchedAt)
+	}
+
+	after, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("Expected the cache file to remain, got %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("Expected a read-only cache to be left untouched")
+	}
+
+	if requests, revalidations := fake.counts(); requests != 2 || revalidations != 1 {
+		t.Errorf("Expected one conditional request, got %d requests and %d revalidations", requests, revalidations)
+	}
+}
+
+func TestFetchCatalog_StaleCacheFallback(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+	fake.setDown(true)
+
+	catalog := fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour), WithForceRefresh(true))
+	if catalog.Origin != ORIGIN_STALE_CACHE {
+		t.Errorf("Expected the stale cache to be used, got %s", catalog.Origin)
+	}
+	if catalog.FetchErr == nil {
+		t.Error("Expected the fetch error to be reported with the stale cache")
+	}
+}
+
+func TestFetchCatalog_NoCacheFailure(t *testing.T) {
+	fake := newFakePlayground(t)
+	fake.setDown(true)
+
+	client := NewClient(WithBaseURL(fake.URL), WithCache(filepath.Join(t.TempDir(), SCOPE_CACHE_FILE), time.Hour))
+	if _, err := client.FetchCatalog(t.Context()); err == nil {
+		t.Error("Expected an error without network and cache")
+	}
+}
+
+func TestCachedCatalog(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+	client := NewClient(WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+
+	if _, err := client.CachedCatalog(); err == nil {
+		t.Error("Expected an error before the catalog is cached")
+	}
+
+	fetchCatalog(t, WithBaseURL(fake.URL), WithCache(cachePath, time.Hour))
+
+	catalog, err := client.CachedCatalog()
+	if err != nil {
+		t.Fatalf("Expected the cached catalog, got %v", err)
+	}
+	if catalog.Origin != ORIGIN_CACHE || !catalog.Services.HasService("Drive API") {
+		t.Errorf("Unexpected cached catalog: %s %v", catalog.Origin, catalog.Services.GetAllServiceNames())
+	}
+
+	if requests, _ := fake.counts(); requests != 1 {
+		t.Errorf("Expected reading the cache to skip the network, got %d requests", requests)
+	}
+}
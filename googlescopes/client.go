@@ -15,26 +15,33 @@ type GoogleServices map[string][]Scope
 type Scope struct {
 	URL         string `json:"url"`
 	Description string `json:"description"`
+	Sensitivity string `json:"sensitivity,omitempty"`
 }
 
-type scopeResponse struct {
-	Description string `json:"description"`
-}
-
-type apiInfoResponse struct {
-	IconURL string                     `json:"iconUrl"`
-	Scopes  []map[string]scopeResponse `json:"scopes"`
-}
+const (
+	ORIGIN_NETWORK     = "network"
+	ORIGIN_CACHE       = "cache"
+	ORIGIN_STALE_CACHE = "stale-cache"
+)
 
-type getScopesResponse struct {
-	Success bool                       `json:"success"`
-	Apis    map[string]apiInfoResponse `json:"apis"`
+type Catalog struct {
+	Services  *GoogleServices
+	Origin    string
+	FetchedAt time.Time
+	FetchErr  error
 }
 
 type Client struct {
 	httpClient    *http.Client
 	baseURL       string
 	scopeEndpoint string
+	sources       []ScopeSource
+	cachePath     string
+	cacheTTL      time.Duration
+	forceRefresh  bool
+	readOnly      bool
+	useSnapshot   bool
+	sensitivity   map[string]string
 }
 
 type ClientOption func(*Client)
@@ -57,6 +64,43 @@ func WithScopeEndpoint(scopeEndpoint string) ClientOption {
 	}
 }
 
+func WithSources(sources ...ScopeSource) ClientOption {
+	return func(c *Client) {
+		c.sources = sources
+	}
+}
+
+func WithCache(cachePath string, cacheTTL time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cachePath = cachePath
+		c.cacheTTL = cacheTTL
+	}
+}
+
+func WithForceRefresh(forceRefresh bool) ClientOption {
+	return func(c *Client) {
+		c.forceRefresh = forceRefresh
+	}
+}
+
+func WithReadOnlyCache(readOnly bool) ClientOption {
+	return func(c *Client) {
+		c.readOnly = readOnly
+	}
+}
+
+func WithSnapshotFallback(useSnapshot bool) ClientOption {
+	return func(c *Client) {
+		c.useSnapshot = useSnapshot
+	}
+}
+
+func WithSensitivityOverrides(overrides map[string]string) ClientOption {
+	return func(c *Client) {
+		c.sensitivity = overrides
+	}
+}
+
 func NewClient(options ...ClientOption) *Client {
 	client := &Client{
 		httpClient: &http.Client{
@@ -78,63 +122,123 @@ func (c *Client) FetchScopes() (*GoogleServices, error) {
 }
 
 func (c *Client) FetchScopesWithContext(ctx context.Context) (*GoogleServices, error) {
-	url := fmt.Sprintf("%s/%s", c.baseURL, c.scopeEndpoint)
+	catalog, err := c.FetchCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Services, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+func (c *Client) FetchCatalog(ctx context.Context) (*Catalog, error) {
+	catalog, err := c.fetchCatalog(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error creating GET request: %w", err)
+		return nil, err
 	}
+	return c.classify(catalog)
+}
 
-	req.Header.Set("Accept", "application/json")
+func (c *Client) fetchCatalog(ctx context.Context) (*Catalog, error) {
+	sources := c.scopeSources()
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making GET request: %w", err)
+	var cached *cacheEntry
+	if c.cachePath != "" {
+		cached, _ = loadCache(c.cachePath)
+	}
+	if cached == nil {
+		cached = &cacheEntry{Sources: make(map[string]*sourceEntry)}
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d - %s", resp.StatusCode, resp.Status)
+	if err := readLocalSources(ctx, sources, cached); err != nil {
+		return nil, err
 	}
 
-	var tempAPIs getScopesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tempAPIs); err != nil {
-		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	if !c.forceRefresh && cached.isFresh(sources, c.cacheTTL) {
+		return cached.catalog(sources, ORIGIN_CACHE), nil
 	}
 
-	if !tempAPIs.Success {
-		return nil, fmt.Errorf("API returned success=false")
+	origin := ORIGIN_CACHE
+	updated := false
+	var fetchErr error
+	for _, source := range sources {
+		if isLocalSource(source) {
+			continue
+		}
+		previous := cached.Sources[source.Name()]
+
+		entry, err := fetchSource(ctx, source, previous)
+		if err != nil {
+			if fetchErr == nil {
+				fetchErr = fmt.Errorf("%s: %w", source.Name(), err)
+			}
+			continue
+		}
+
+		if entry != previous {
+			origin = ORIGIN_NETWORK
+		}
+		cached.Sources[source.Name()] = entry
+		updated = true
 	}
 
-	return c.reorganizeScopes(tempAPIs.Apis), nil
-}
+	if updated && c.cachePath != "" && !c.readOnly {
+		_ = saveCache(c.cachePath, cached.withoutLocal(sources))
+	}
 
-func (c *Client) reorganizeScopes(tempAPIs map[string]apiInfoResponse) *GoogleServices {
-	googleServices := make(GoogleServices, len(tempAPIs))
+	if fetchErr == nil {
+		return cached.catalog(sources, origin), nil
+	}
 
-	for apiName, apiInfo := range tempAPIs {
-		scopes := make([]Scope, 0, len(apiInfo.Scopes))
+	if cached.covers(sources) {
+		catalog := cached.catalog(sources, ORIGIN_STALE_CACHE)
+		catalog.FetchErr = fetchErr
+		return catalog, nil
+	}
 
-		for _, scopeMap := range apiInfo.Scopes {
-			for scopeURL, scopeInfo := range scopeMap {
-				scope := Scope{
-					URL:         scopeURL,
-					Description: scopeInfo.Description,
-				}
-				scopes = append(scopes, scope)
-			}
+	if c.useSnapshot {
+		if snapshot, err := Snapshot(); err == nil {
+			return &Catalog{Services: snapshot, Origin: ORIGIN_SNAPSHOT, FetchedAt: SnapshotDate(), FetchErr: fetchErr}, nil
 		}
+	}
 
-		sort.Slice(scopes, func(i, j int) bool {
-			return scopes[i].URL < scopes[j].URL
-		})
+	return nil, fetchErr
+}
+
+func (c *Client) CachedCatalog() (*Catalog, error) {
+	if c.cachePath == "" {
+		return nil, fmt.Errorf("no scope cache configured")
+	}
 
-		googleServices[apiName] = scopes
+	cached, err := loadCache(c.cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading scope cache: %w", err)
+	}
+
+	sources := c.scopeSources()
+	if err := readLocalSources(context.Background(), sources, cached); err != nil {
+		return nil, err
+	}
+	if !cached.covers(sources) {
+		return nil, fmt.Errorf("scope cache %s does not cover every configured source", c.cachePath)
 	}
 
-	return &googleServices
+	return c.classify(cached.catalog(sources, ORIGIN_CACHE))
+}
+
+func (c *Client) classify(catalog *Catalog) (*Catalog, error) {
+	table, err := NewSensitivityTable(c.sensitivity)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog.Services.ClassifySensitivity(table)
+	return catalog, nil
+}
+
+func (c *Client) scopeSources() []ScopeSource {
+	if len(c.sources) > 0 {
+		return c.sources
+	}
+	return []ScopeSource{NewPlaygroundSource(c.httpClient, c.baseURL, c.scopeEndpoint)}
 }
 
 func (gs *GoogleServices) GetScopesForService(serviceName string) ([]Scope, bool) {
@@ -199,6 +303,23 @@ func (gs *GoogleServices) GetScopeByURL(searchURL string) (*Scope, string, bool)
 	return nil, "", false
 }
 
+func (gs *GoogleServices) UnknownScopes(scopeURLs []string) []string {
+	known := make(map[string]bool)
+	for _, scopes := range *gs {
+		for _, scope := range scopes {
+			known[scope.URL] = true
+		}
+	}
+
+	var unknown []string
+	for _, scopeURL := range scopeURLs {
+		if !known[scopeURL] {
+			unknown = append(unknown, scopeURL)
+		}
+	}
+	return unknown
+}
+
 func (gs *GoogleServices) IsEmpty() bool {
 	return len(*gs) == 0
 }
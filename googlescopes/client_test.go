@@ -187,6 +187,27 @@ func TestGoogleServices_Methods(t *testing.T) {
 	}
 }
 
+func TestGoogleServices_UnknownScopes(t *testing.T) {
+	services := GoogleServices{
+		"Drive API": []Scope{
+			{URL: "https://www.googleapis.com/auth/drive", Description: "Drive access"},
+		},
+	}
+
+	unknown := services.UnknownScopes([]string{
+		"https://www.googleapis.com/auth/drive",
+		"https://www.googleapis.com/auth/not-a-scope",
+	})
+
+	if len(unknown) != 1 || unknown[0] != "https://www.googleapis.com/auth/not-a-scope" {
+		t.Errorf("Expected only the unknown scope to be reported, got %v", unknown)
+	}
+
+	if unknown := services.UnknownScopes([]string{"https://www.googleapis.com/auth/drive"}); len(unknown) != 0 {
+		t.Errorf("Expected no unknown scopes, got %v", unknown)
+	}
+}
+
 func TestFetchScopesWithContext_Timeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(100 * time.Millisecond)
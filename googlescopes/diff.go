@@ -0,0 +1,117 @@
+package googlescopes
+
+import "sort"
+
+type ScopeChange struct {
+	Service     string `json:"service"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type DescriptionChange struct {
+	Service        string `json:"service"`
+	URL            string `json:"url"`
+	OldDescription string `json:"old_description"`
+	NewDescription string `json:"new_description"`
+}
+
+type CatalogDiff struct {
+	AddedServices       []string            `json:"added_services"`
+	RemovedServices     []string            `json:"removed_services"`
+	AddedScopes         []ScopeChange       `json:"added_scopes"`
+	RemovedScopes       []ScopeChange       `json:"removed_scopes"`
+	ChangedDescriptions []DescriptionChange `json:"changed_descriptions"`
+}
+
+func Diff(oldServices, newServices *GoogleServices) *CatalogDiff {
+	diff := &CatalogDiff{
+		AddedServices:       []string{},
+		RemovedServices:     []string{},
+		AddedScopes:         []ScopeChange{},
+		RemovedScopes:       []ScopeChange{},
+		ChangedDescriptions: []DescriptionChange{},
+	}
+
+	for _, serviceName := range newServices.GetAllServiceNames() {
+		if !oldServices.HasService(serviceName) {
+			diff.AddedServices = append(diff.AddedServices, serviceName)
+		}
+	}
+
+	for _, serviceName := range oldServices.GetAllServiceNames() {
+		if !newServices.HasService(serviceName) {
+			diff.RemovedServices = append(diff.RemovedServices, serviceName)
+		}
+	}
+
+	for _, serviceName := range unionServiceNames(oldServices, newServices) {
+		oldScopes := scopesByURL((*oldServices)[serviceName])
+		newScopes := scopesByURL((*newServices)[serviceName])
+
+		for _, scopeURL := range sortedURLs(newScopes) {
+			oldScope, exists := oldScopes[scopeURL]
+			newScope := newScopes[scopeURL]
+
+			if !exists {
+				diff.AddedScopes = append(diff.AddedScopes, ScopeChange{Service: serviceName, URL: scopeURL, Description: newScope.Description})
+			} else if oldScope.Description != newScope.Description {
+				diff.ChangedDescriptions = append(diff.ChangedDescriptions, DescriptionChange{
+					Service:        serviceName,
+					URL:            scopeURL,
+					OldDescription: oldScope.Description,
+					NewDescription: newScope.Description,
+				})
+			}
+		}
+
+		for _, scopeURL := range sortedURLs(oldScopes) {
+			if _, exists := newScopes[scopeURL]; !exists {
+				diff.RemovedScopes = append(diff.RemovedScopes, ScopeChange{Service: serviceName, URL: scopeURL, Description: oldScopes[scopeURL].Description})
+			}
+		}
+	}
+
+	return diff
+}
+
+func (d *CatalogDiff) Count() int {
+	return len(d.AddedServices) + len(d.RemovedServices) + len(d.AddedScopes) + len(d.RemovedScopes) + len(d.ChangedDescriptions)
+}
+
+func (d *CatalogDiff) IsEmpty() bool {
+	return d.Count() == 0
+}
+
+func unionServiceNames(a, b *GoogleServices) []string {
+	seen := make(map[string]bool)
+	for name := range *a {
+		seen[name] = true
+	}
+	for name := range *b {
+		seen[name] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func scopesByURL(scopes []Scope) map[string]Scope {
+	byURL := make(map[string]Scope, len(scopes))
+	for _, scope := range scopes {
+		byURL[scope.URL] = scope
+	}
+	return byURL
+}
+
+func sortedURLs(scopes map[string]Scope) []string {
+	urls := make([]string, 0, len(scopes))
+	for scopeURL := range scopes {
+		urls = append(urls, scopeURL)
+	}
+	sort.Strings(urls)
+	return urls
+}
@@ -0,0 +1,79 @@
+package googlescopes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldServices := &GoogleServices{
+		"Drive API": {
+			{URL: "https://www.googleapis.com/auth/drive", Description: "Full access to Drive"},
+			{URL: "https://www.googleapis.com/auth/drive.photos.readonly", Description: "View Drive photos"},
+		},
+		"Legacy API": {
+			{URL: "https://www.googleapis.com/auth/legacy", Description: "Legacy access"},
+		},
+	}
+	newServices := &GoogleServices{
+		"Drive API": {
+			{URL: "https://www.googleapis.com/auth/drive", Description: "See, edit, create, and delete all of your Google Drive files"},
+			{URL: "https://www.googleapis.com/auth/drive.file", Description: "Per-file access"},
+		},
+		"Gmail API": {
+			{URL: "https://mail.google.com/", Description: "Full access to Gmail"},
+		},
+	}
+
+	diff := Diff(oldServices, newServices)
+
+	if len(diff.AddedServices) != 1 || diff.AddedServices[0] != "Gmail API" {
+		t.Errorf("Expected Gmail API to be added, got %v", diff.AddedServices)
+	}
+	if len(diff.RemovedServices) != 1 || diff.RemovedServices[0] != "Legacy API" {
+		t.Errorf("Expected Legacy API to be removed, got %v", diff.RemovedServices)
+	}
+
+	if len(diff.AddedScopes) != 2 {
+		t.Fatalf("Expected 2 added scopes, got %v", diff.AddedScopes)
+	}
+	if diff.AddedScopes[0].URL != "https://www.googleapis.com/auth/drive.file" || diff.AddedScopes[1].Service != "Gmail API" {
+		t.Errorf("Expected added scopes sorted by service, got %v", diff.AddedScopes)
+	}
+
+	if len(diff.RemovedScopes) != 2 {
+		t.Errorf("Expected 2 removed scopes, got %v", diff.RemovedScopes)
+	}
+
+	if len(diff.ChangedDescriptions) != 1 {
+		t.Fatalf("Expected 1 changed description, got %v", diff.ChangedDescriptions)
+	}
+	change := diff.ChangedDescriptions[0]
+	if change.OldDescription != "Full access to Drive" || change.Service != "Drive API" {
+		t.Errorf("Unexpected description change: %+v", change)
+	}
+
+	if diff.Count() != 7 || diff.IsEmpty() {
+		t.Errorf("Expected 7 differences, got %d", diff.Count())
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	services, err := Snapshot()
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	diff := Diff(services, services)
+	if !diff.IsEmpty() {
+		t.Errorf("Expected no differences, got %+v", diff)
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatalf("Failed to marshal diff: %v", err)
+	}
+	if string(data) != `{"added_services":[],"removed_services":[],"added_scopes":[],"removed_scopes":[],"changed_descriptions":[]}` {
+		t.Errorf("Expected empty JSON lists, got %s", data)
+	}
+}
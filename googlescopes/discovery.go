@@ -0,0 +1,160 @@
+package googlescopes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+)
+
+const (
+	DISCOVERY_DIRECTORY_URL = "https://www.googleapis.com/discovery/v1/apis?preferred=true"
+	DISCOVERY_CONCURRENCY   = 8
+)
+
+type discoveryDirectory struct {
+	Items []discoveryItem `json:"items"`
+}
+
+type discoveryItem struct {
+	Name             string `json:"name"`
+	Version          string `json:"version"`
+	Title            string `json:"title"`
+	DiscoveryRestURL string `json:"discoveryRestUrl"`
+}
+
+type discoveryDocument struct {
+	Auth struct {
+		OAuth2 struct {
+			Scopes map[string]scopeResponse `json:"scopes"`
+		} `json:"oauth2"`
+	} `json:"auth"`
+}
+
+type DiscoverySource struct {
+	httpClient   *http.Client
+	directoryURL string
+	apis         []string
+}
+
+func NewDiscoverySource(httpClient *http.Client, directoryURL string, apis []string) *DiscoverySource {
+	if directoryURL == "" {
+		directoryURL = DISCOVERY_DIRECTORY_URL
+	}
+
+	return &DiscoverySource{
+		httpClient:   httpClient,
+		directoryURL: directoryURL,
+		apis:         apis,
+	}
+}
+
+func (d *DiscoverySource) Name() string {
+	return "discovery:" + d.directoryURL
+}
+
+func (d *DiscoverySource) FetchServices(ctx context.Context) (*GoogleServices, error) {
+	var directory discoveryDirectory
+	if err := d.getJSON(ctx, d.directoryURL, &directory); err != nil {
+		return nil, fmt.Errorf("error fetching discovery directory: %w", err)
+	}
+
+	items := make([]discoveryItem, 0, len(directory.Items))
+	for _, item := range directory.Items {
+		if len(d.apis) > 0 && !slices.Contains(d.apis, item.Name) {
+			continue
+		}
+		if item.DiscoveryRestURL != "" {
+			items = append(items, item)
+		}
+	}
+
+	services := make(GoogleServices)
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	semaphore := make(chan struct{}, DISCOVERY_CONCURRENCY)
+
+	for _, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var document discoveryDocument
+			err := d.getJSON(ctx, item.DiscoveryRestURL, &document)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("error fetching discovery document for %s %s: %w", item.Name, item.Version, err)
+				}
+				return
+			}
+
+			if len(document.Auth.OAuth2.Scopes) == 0 {
+				return
+			}
+
+			scopes := make([]Scope, 0, len(document.Auth.OAuth2.Scopes))
+			for scopeURL, scopeInfo := range document.Auth.OAuth2.Scopes {
+				scopes = append(scopes, Scope{URL: scopeURL, Description: scopeInfo.Description})
+			}
+			sort.Slice(scopes, func(i, j int) bool {
+				return scopes[i].URL < scopes[j].URL
+			})
+
+			services[discoveryServiceName(item)] = scopes
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return &services, nil
+}
+
+func (d *DiscoverySource) getJSON(ctx context.Context, url string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating GET request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making GET request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error: %d - %s", resp.StatusCode, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	return nil
+}
+
+func discoveryServiceName(item discoveryItem) string {
+	title := item.Title
+	if title == "" {
+		title = item.Name
+	}
+	return title + " " + item.Version
+}
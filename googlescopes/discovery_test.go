@@ -0,0 +1,88 @@
+package googlescopes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newFakeDiscovery(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("GET /apis", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"items": [
+			{"name": "drive", "version": "v3", "title": "Google Drive API", "discoveryRestUrl": "%[1]s/drive/v3/rest"},
+			{"name": "gmail", "version": "v1", "title": "Gmail API", "discoveryRestUrl": "%[1]s/gmail/v1/rest"},
+			{"name": "discovery", "version": "v1", "title": "API Discovery Service", "discoveryRestUrl": "%[1]s/discovery/v1/rest"}
+		]}`, server.URL)
+	})
+	mux.HandleFunc("GET /drive/v3/rest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"auth": {"oauth2": {"scopes": {
+			"https://www.googleapis.com/auth/drive.readonly": {"description": "See and download all your Google Drive files"},
+			"https://www.googleapis.com/auth/drive": {"description": "See, edit, create, and delete all of your Google Drive files"}
+		}}}}`)
+	})
+	mux.HandleFunc("GET /gmail/v1/rest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"auth": {"oauth2": {"scopes": {
+			"https://mail.google.com/": {"description": "Read, compose, send, and permanently delete all your email from Gmail"}
+		}}}}`)
+	})
+	mux.HandleFunc("GET /discovery/v1/rest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"name": "discovery"}`)
+	})
+
+	return server
+}
+
+func TestDiscoverySource_FetchServices(t *testing.T) {
+	server := newFakeDiscovery(t)
+
+	services, err := NewDiscoverySource(server.Client(), server.URL+"/apis", nil).FetchServices(t.Context())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if services.GetServiceCount() != 2 {
+		t.Errorf("Expected APIs without scopes to be skipped, got %v", services.GetAllServiceNames())
+	}
+
+	drive, exists := services.GetScopesForService("Google Drive API v3")
+	if !exists || len(drive) != 2 {
+		t.Fatalf("Expected 2 Drive scopes, got %v", drive)
+	}
+	if drive[0].URL != "https://www.googleapis.com/auth/drive" {
+		t.Errorf("Expected scopes sorted by URL, got %v", drive)
+	}
+}
+
+func TestDiscoverySource_FilterAPIs(t *testing.T) {
+	server := newFakeDiscovery(t)
+
+	services, err := NewDiscoverySource(server.Client(), server.URL+"/apis", []string{"gmail"}).FetchServices(t.Context())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if names := services.GetAllServiceNames(); len(names) != 1 || names[0] != "Gmail API v1" {
+		t.Errorf("Expected only the Gmail API, got %v", names)
+	}
+}
+
+func TestDiscoverySource_DocumentError(t *testing.T) {
+	server := newFakeDiscovery(t)
+
+	source := NewDiscoverySource(server.Client(), server.URL+"/missing", nil)
+	if _, err := source.FetchServices(t.Context()); err == nil {
+		t.Error("Expected an error when the directory is unavailable")
+	}
+}
+
+func TestNewDiscoverySource_DefaultDirectory(t *testing.T) {
+	source := NewDiscoverySource(http.DefaultClient, "", nil)
+	if source.Name() != "discovery:"+DISCOVERY_DIRECTORY_URL {
+		t.Errorf("Expected the default directory, got %s", source.Name())
+	}
+}
@@ -0,0 +1,48 @@
+package googlescopes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (f *FileSource) Name() string {
+	return "file:" + f.path
+}
+
+func (f *FileSource) isLocal() bool {
+	return true
+}
+
+func (f *FileSource) FetchServices(ctx context.Context) (*GoogleServices, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scope file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(f.path), ".json") {
+		return FromJSON(data)
+	}
+
+	var services GoogleServices
+	if err := yaml.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("error parsing scope file %s: %w", f.path, err)
+	}
+	if services == nil {
+		services = make(GoogleServices)
+	}
+
+	return &services, nil
+}
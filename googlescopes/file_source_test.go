@@ -0,0 +1,104 @@
+package googlescopes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSource_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scopes.yaml")
+	content := `Internal API:
+  - url: https://example.com/auth/internal
+    description: Internal access
+  - url: https://example.com/auth/internal.readonly
+    description: Read-only internal access
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write scope file: %v", err)
+	}
+
+	services, err := NewFileSource(path).FetchServices(t.Context())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	scopes, exists := services.GetScopesForService("Internal API")
+	if !exists || len(scopes) != 2 {
+		t.Fatalf("Expected 2 internal scopes, got %v", scopes)
+	}
+	if scopes[0].URL != "https://example.com/auth/internal" || scopes[0].Description != "Internal access" {
+		t.Errorf("Unexpected scope: %+v", scopes[0])
+	}
+}
+
+func TestFileSource_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scopes.json")
+	content := `{"Internal API": [{"url": "https://example.com/auth/internal", "description": "Internal access"}]}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write scope file: %v", err)
+	}
+
+	services, err := NewFileSource(path).FetchServices(t.Context())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if services.GetTotalScopeCount() != 1 {
+		t.Errorf("Expected 1 scope, got %d", services.GetTotalScopeCount())
+	}
+}
+
+func TestFileSource_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+	source := NewFileSource(path)
+
+	if source.Name() != "file:"+path {
+		t.Errorf("Unexpected source name: %s", source.Name())
+	}
+	if _, err := source.FetchServices(t.Context()); err == nil {
+		t.Error("Expected an error for a missing scope file")
+	}
+}
+
+func TestFetchCatalog_FileSourceIsNotCached(t *testing.T) {
+	fake := newFakePlayground(t)
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, SCOPE_CACHE_FILE)
+	path := filepath.Join(dir, "scopes.yaml")
+	writeScopes := func(description string) {
+		content := "Internal API:\n  - url: https://example.com/auth/internal\n    description: " + description + "\n"
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write scope file: %v", err)
+		}
+	}
+	sources := []ScopeSource{NewPlaygroundSource(NewClient().httpClient, fake.URL, "getScopes"), NewFileSource(path)}
+
+	writeScopes("Before")
+	fetchCatalog(t, WithSources(sources...), WithCache(cachePath, time.Hour))
+
+	cached, err := loadCache(cachePath)
+	if err != nil {
+		t.Fatalf("Expected a saved cache, got %v", err)
+	}
+	if _, exists := cached.Sources["file:"+path]; exists {
+		t.Error("Expected the file source to be left out of the cache")
+	}
+
+	writeScopes("After")
+	catalog := fetchCatalog(t, WithSources(sources...), WithCache(cachePath, time.Hour))
+	if catalog.Origin != ORIGIN_CACHE {
+		t.Errorf("Expected the playground to be served from the cache, got %s", catalog.Origin)
+	}
+	if scopes, _ := catalog.Services.GetScopesForService("Internal API"); len(scopes) != 1 || scopes[0].Description != "After" {
+		t.Errorf("Expected the edited scope file to be read again, got %v", scopes)
+	}
+
+	cachedCatalog, err := NewClient(WithSources(sources...), WithCache(cachePath, time.Hour)).CachedCatalog()
+	if err != nil {
+		t.Fatalf("Expected the cached catalog to read the scope file, got %v", err)
+	}
+	if !cachedCatalog.Services.HasService("Internal API") {
+		t.Error("Expected the scope file in the cached catalog")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"google-auth-wizard/googlescopes"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const (
+	SNAPSHOT_FILE      = "snapshot.json"
+	SNAPSHOT_DATE_FILE = "snapshot.go"
+	MIN_SERVICES       = 50
+)
+
+var snapshotDatePattern = regexp.MustCompile(`(SNAPSHOT_DATE\s*=\s*)"[0-9-]*"`)
+
+func main() {
+	baseURL := flag.String("url", "https://developers.google.com/oauthplayground", "OAuth Playground base URL")
+	scopeEndpoint := flag.String("endpoint", "getScopes", "Scope endpoint of the OAuth Playground")
+	dir := flag.String("dir", ".", "Directory of the googlescopes package")
+	minServices := flag.Int("min-services", MIN_SERVICES, "Refuse to write a catalog with fewer services")
+	timeout := flag.Duration("timeout", time.Minute, "Timeout of the catalog request")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	source := googlescopes.NewPlaygroundSource(&http.Client{Timeout: *timeout}, *baseURL, *scopeEndpoint)
+	if err := generate(ctx, source, *dir, *minServices, time.Now().UTC()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(ctx context.Context, source googlescopes.ScopeSource, dir string, minServices int, date time.Time) error {
+	services, err := source.FetchServices(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the scope catalog: %w", err)
+	}
+
+	if services.GetServiceCount() < minServices {
+		return fmt.Errorf("scope catalog has %d services, expected at least %d", services.GetServiceCount(), minServices)
+	}
+
+	data, err := services.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal the scope catalog: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SNAPSHOT_FILE), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SNAPSHOT_FILE, err)
+	}
+
+	if err := updateSnapshotDate(filepath.Join(dir, SNAPSHOT_DATE_FILE), date); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote %d services and %d scopes to %s", services.GetServiceCount(), services.GetTotalScopeCount(), SNAPSHOT_FILE)
+	return nil
+}
+
+func updateSnapshotDate(path string, date time.Time) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if !snapshotDatePattern.Match(source) {
+		return fmt.Errorf("no SNAPSHOT_DATE constant found in %s", path)
+	}
+
+	updated := snapshotDatePattern.ReplaceAll(source, []byte(`${1}"`+date.Format(time.DateOnly)+`"`))
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
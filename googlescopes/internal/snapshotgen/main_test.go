@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"google-auth-wizard/googlescopes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type staticSource struct {
+	services googlescopes.GoogleServices
+	err      error
+}
+
+func (s *staticSource) Name() string {
+	return "static"
+}
+
+func (s *staticSource) FetchServices(ctx context.Context) (*googlescopes.GoogleServices, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &s.services, nil
+}
+
+func writeSnapshotGo(t *testing.T, dir string) {
+	t.Helper()
+	content := "package googlescopes\n\nconst (\n\tORIGIN_SNAPSHOT = \"snapshot\"\n\tSNAPSHOT_DATE   = \"2020-01-01\"\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, SNAPSHOT_DATE_FILE), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write snapshot.go: %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	writeSnapshotGo(t, dir)
+
+	source := &staticSource{services: googlescopes.GoogleServices{
+		"Drive API": {{URL: "https://www.googleapis.com/auth/drive", Description: "Drive"}},
+		"Gmail API": {{URL: "https://mail.google.com/", Description: "Gmail"}},
+	}}
+	date := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	if err := generate(context.Background(), source, dir, 2, date); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, SNAPSHOT_FILE))
+	if err != nil {
+		t.Fatalf("Expected the snapshot to be written, got %v", err)
+	}
+	services, err := googlescopes.FromJSON(data)
+	if err != nil {
+		t.Fatalf("Expected the snapshot to load with FromJSON, got %v", err)
+	}
+	if services.GetServiceCount() != 2 {
+		t.Errorf("Expected 2 services, got %d", services.GetServiceCount())
+	}
+
+	snapshotGo, err := os.ReadFile(filepath.Join(dir, SNAPSHOT_DATE_FILE))
+	if err != nil {
+		t.Fatalf("Failed to read snapshot.go: %v", err)
+	}
+	if !strings.Contains(string(snapshotGo), `SNAPSHOT_DATE   = "2026-10-16"`) {
+		t.Errorf("Expected SNAPSHOT_DATE to be updated, got:\n%s", snapshotGo)
+	}
+}
+
+func TestGenerate_RefusesIncompleteCatalog(t *testing.T) {
+	dir := t.TempDir()
+	writeSnapshotGo(t, dir)
+
+	source := &staticSource{services: googlescopes.GoogleServices{
+		"Drive API": {{URL: "https://www.googleapis.com/auth/drive", Description: "Drive"}},
+	}}
+	if err := generate(context.Background(), source, dir, 2, time.Now()); err == nil {
+		t.Error("Expected an error for a catalog with too few services")
+	}
+
+	if err := generate(context.Background(), &staticSource{err: errors.New("offline")}, dir, 0, time.Now()); err == nil {
+		t.Error("Expected an error when the catalog cannot be fetched")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, SNAPSHOT_FILE)); !os.IsNotExist(err) {
+		t.Error("Expected no snapshot to be written")
+	}
+}
@@ -0,0 +1,128 @@
+package googlescopes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type scopeResponse struct {
+	Description string `json:"description"`
+}
+
+type apiInfoResponse struct {
+	IconURL string                     `json:"iconUrl"`
+	Scopes  []map[string]scopeResponse `json:"scopes"`
+}
+
+type getScopesResponse struct {
+	Success bool                       `json:"success"`
+	Apis    map[string]apiInfoResponse `json:"apis"`
+}
+
+type PlaygroundSource struct {
+	httpClient    *http.Client
+	baseURL       string
+	scopeEndpoint string
+}
+
+func NewPlaygroundSource(httpClient *http.Client, baseURL, scopeEndpoint string) *PlaygroundSource {
+	return &PlaygroundSource{
+		httpClient:    httpClient,
+		baseURL:       baseURL,
+		scopeEndpoint: scopeEndpoint,
+	}
+}
+
+func (p *PlaygroundSource) Name() string {
+	return "playground:" + p.baseURL
+}
+
+func (p *PlaygroundSource) FetchServices(ctx context.Context) (*GoogleServices, error) {
+	entry, err := p.fetchIfModified(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &entry.Services, nil
+}
+
+func (p *PlaygroundSource) fetchIfModified(ctx context.Context, previous *sourceEntry) (*sourceEntry, error) {
+	url := fmt.Sprintf("%s/%s", p.baseURL, p.scopeEndpoint)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating GET request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if previous != nil {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making GET request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		previous.FetchedAt = time.Now()
+		return previous, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d - %s", resp.StatusCode, resp.Status)
+	}
+
+	var tempAPIs getScopesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tempAPIs); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	if !tempAPIs.Success {
+		return nil, fmt.Errorf("API returned success=false")
+	}
+
+	return &sourceEntry{
+		FetchedAt:    time.Now(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Services:     *reorganizeScopes(tempAPIs.Apis),
+	}, nil
+}
+
+func reorganizeScopes(tempAPIs map[string]apiInfoResponse) *GoogleServices {
+	googleServices := make(GoogleServices, len(tempAPIs))
+
+	for apiName, apiInfo := range tempAPIs {
+		scopes := make([]Scope, 0, len(apiInfo.Scopes))
+
+		for _, scopeMap := range apiInfo.Scopes {
+			for scopeURL, scopeInfo := range scopeMap {
+				scope := Scope{
+					URL:         scopeURL,
+					Description: scopeInfo.Description,
+				}
+				scopes = append(scopes, scope)
+			}
+		}
+
+		sort.Slice(scopes, func(i, j int) bool {
+			return scopes[i].URL < scopes[j].URL
+		})
+
+		googleServices[apiName] = scopes
+	}
+
+	return &googleServices
+}
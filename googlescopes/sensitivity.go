@@ -0,0 +1,83 @@
+package googlescopes
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	SENSITIVITY_UNKNOWN       = ""
+	SENSITIVITY_NON_SENSITIVE = "non-sensitive"
+	SENSITIVITY_SENSITIVE     = "sensitive"
+	SENSITIVITY_RESTRICTED    = "restricted"
+)
+
+//go:embed sensitivity.json
+var sensitivityJSON []byte
+
+type SensitivityTable map[string]string
+
+func NewSensitivityTable(overrides map[string]string) (SensitivityTable, error) {
+	var levels map[string][]string
+	if err := json.Unmarshal(sensitivityJSON, &levels); err != nil {
+		return nil, fmt.Errorf("error parsing sensitivity table: %w", err)
+	}
+
+	table := make(SensitivityTable)
+	for level, scopeURLs := range levels {
+		if !IsValidSensitivity(level) {
+			return nil, fmt.Errorf("invalid sensitivity level %q in sensitivity table", level)
+		}
+		for _, scopeURL := range scopeURLs {
+			table[scopeURL] = level
+		}
+	}
+
+	for scopeURL, level := range overrides {
+		if !IsValidSensitivity(level) {
+			return nil, fmt.Errorf("invalid sensitivity %q for %s", level, scopeURL)
+		}
+		table[scopeURL] = level
+	}
+
+	return table, nil
+}
+
+func IsValidSensitivity(level string) bool {
+	switch level {
+	case SENSITIVITY_NON_SENSITIVE, SENSITIVITY_SENSITIVE, SENSITIVITY_RESTRICTED:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t SensitivityTable) Level(scopeURL string) string {
+	if level, exists := t[scopeURL]; exists {
+		return level
+	}
+	return SENSITIVITY_UNKNOWN
+}
+
+func (t SensitivityTable) Count(scopeURLs []string) (restricted int, sensitive int, unknown int) {
+	for _, scopeURL := range scopeURLs {
+		switch t.Level(scopeURL) {
+		case SENSITIVITY_RESTRICTED:
+			restricted++
+		case SENSITIVITY_SENSITIVE:
+			sensitive++
+		case SENSITIVITY_UNKNOWN:
+			unknown++
+		}
+	}
+	return restricted, sensitive, unknown
+}
+
+func (gs *GoogleServices) ClassifySensitivity(table SensitivityTable) {
+	for _, scopes := range *gs {
+		for i := range scopes {
+			scopes[i].Sensitivity = table.Level(scopes[i].URL)
+		}
+	}
+}
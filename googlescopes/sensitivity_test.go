@@ -0,0 +1,78 @@
+package googlescopes
+
+import "testing"
+
+func TestNewSensitivityTable(t *testing.T) {
+	table, err := NewSensitivityTable(nil)
+	if err != nil {
+		t.Fatalf("Expected the embedded sensitivity table to parse, got %v", err)
+	}
+
+	tests := map[string]string{
+		"https://mail.google.com/":                   SENSITIVITY_RESTRICTED,
+		"https://www.googleapis.com/auth/drive":      SENSITIVITY_RESTRICTED,
+		"https://www.googleapis.com/auth/calendar":   SENSITIVITY_SENSITIVE,
+		"https://www.googleapis.com/auth/drive.file": SENSITIVITY_NON_SENSITIVE,
+		"openid": SENSITIVITY_NON_SENSITIVE,
+		"https://www.googleapis.com/auth/unknown.scope": SENSITIVITY_UNKNOWN,
+	}
+	for scopeURL, expected := range tests {
+		if level := table.Level(scopeURL); level != expected {
+			t.Errorf("Expected %s to be %s, got %s", scopeURL, expected, level)
+		}
+	}
+
+	restricted, sensitive, unknown := table.Count([]string{
+		"https://mail.google.com/",
+		"https://www.googleapis.com/auth/drive.readonly",
+		"https://www.googleapis.com/auth/calendar",
+		"openid",
+		"https://example.com/auth/internal",
+	})
+	if restricted != 2 || sensitive != 1 || unknown != 1 {
+		t.Errorf("Expected 2 restricted, 1 sensitive and 1 unknown scopes, got %d, %d and %d", restricted, sensitive, unknown)
+	}
+}
+
+func TestNewSensitivityTable_Overrides(t *testing.T) {
+	table, err := NewSensitivityTable(map[string]string{
+		"https://www.googleapis.com/auth/drive": SENSITIVITY_NON_SENSITIVE,
+		"https://example.com/auth/internal":     SENSITIVITY_RESTRICTED,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if level := table.Level("https://www.googleapis.com/auth/drive"); level != SENSITIVITY_NON_SENSITIVE {
+		t.Errorf("Expected the override to win, got %s", level)
+	}
+	if level := table.Level("https://example.com/auth/internal"); level != SENSITIVITY_RESTRICTED {
+		t.Errorf("Expected a custom scope to be classified, got %s", level)
+	}
+
+	if _, err := NewSensitivityTable(map[string]string{"openid": "secret"}); err == nil {
+		t.Error("Expected an error for an invalid sensitivity level")
+	}
+}
+
+func TestFetchCatalog_ClassifiesSensitivity(t *testing.T) {
+	fake := newFakePlayground(t)
+
+	catalog := fetchCatalog(t, WithBaseURL(fake.URL))
+	drive, _ := catalog.Services.GetScopesForService("Drive API")
+	if drive[0].Sensitivity != SENSITIVITY_RESTRICTED {
+		t.Errorf("Expected the Drive scope to be restricted, got %q", drive[0].Sensitivity)
+	}
+
+	catalog = fetchCatalog(t, WithBaseURL(fake.URL), WithSensitivityOverrides(map[string]string{
+		"https://www.googleapis.com/auth/drive": SENSITIVITY_SENSITIVE,
+	}))
+	drive, _ = catalog.Services.GetScopesForService("Drive API")
+	if drive[0].Sensitivity != SENSITIVITY_SENSITIVE {
+		t.Errorf("Expected the configured sensitivity, got %q", drive[0].Sensitivity)
+	}
+
+	if _, err := NewClient(WithBaseURL(fake.URL), WithSensitivityOverrides(map[string]string{"openid": "secret"})).FetchCatalog(t.Context()); err == nil {
+		t.Error("Expected an error for an invalid sensitivity override")
+	}
+}
@@ -0,0 +1,25 @@
+package googlescopes
+
+import (
+	_ "embed"
+	"time"
+)
+
+//go:generate go run ./internal/snapshotgen -dir .
+
+const (
+	ORIGIN_SNAPSHOT = "snapshot"
+	SNAPSHOT_DATE   = "2026-10-16"
+)
+
+//go:embed snapshot.json
+var snapshotJSON []byte
+
+func Snapshot() (*GoogleServices, error) {
+	return FromJSON(snapshotJSON)
+}
+
+func SnapshotDate() time.Time {
+	date, _ := time.Parse(time.DateOnly, SNAPSHOT_DATE)
+	return date
+}
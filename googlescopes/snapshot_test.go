@@ -0,0 +1,55 @@
+package googlescopes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	services, err := Snapshot()
+	if err != nil {
+		t.Fatalf("Expected the embedded snapshot to parse, got %v", err)
+	}
+
+	if services.IsEmpty() {
+		t.Fatal("Expected the embedded snapshot to list services")
+	}
+
+	for _, scope := range []string{"openid", "https://www.googleapis.com/auth/drive", "https://mail.google.com/"} {
+		if unknown := services.UnknownScopes([]string{scope}); len(unknown) != 0 {
+			t.Errorf("Expected %s in the snapshot", scope)
+		}
+	}
+
+	if SnapshotDate().IsZero() {
+		t.Errorf("Expected a valid snapshot date, got %q", SNAPSHOT_DATE)
+	}
+}
+
+func TestFetchCatalog_SnapshotFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+
+	if _, err := NewClient(WithBaseURL(server.URL), WithCache(cachePath, time.Hour)).FetchCatalog(t.Context()); err == nil {
+		t.Error("Expected an error when the snapshot fallback is disabled")
+	}
+
+	catalog, err := NewClient(WithBaseURL(server.URL), WithCache(cachePath, time.Hour), WithSnapshotFallback(true)).FetchCatalog(t.Context())
+	if err != nil {
+		t.Fatalf("Expected the snapshot to be used, got %v", err)
+	}
+
+	if catalog.Origin != ORIGIN_SNAPSHOT || catalog.FetchErr == nil {
+		t.Errorf("Expected a snapshot catalog with the fetch error, got %s / %v", catalog.Origin, catalog.FetchErr)
+	}
+	if !catalog.FetchedAt.Equal(SnapshotDate()) {
+		t.Errorf("Expected the snapshot date, got %v", catalog.FetchedAt)
+	}
+}
@@ -0,0 +1,92 @@
+package googlescopes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ScopeSource interface {
+	Name() string
+	FetchServices(ctx context.Context) (*GoogleServices, error)
+}
+
+type conditionalSource interface {
+	fetchIfModified(ctx context.Context, previous *sourceEntry) (*sourceEntry, error)
+}
+
+type localSource interface {
+	isLocal() bool
+}
+
+func isLocalSource(source ScopeSource) bool {
+	local, ok := source.(localSource)
+	return ok && local.isLocal()
+}
+
+func readLocalSources(ctx context.Context, sources []ScopeSource, cached *cacheEntry) error {
+	for _, source := range sources {
+		if !isLocalSource(source) {
+			continue
+		}
+
+		entry, err := fetchSource(ctx, source, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", source.Name(), err)
+		}
+		cached.Sources[source.Name()] = entry
+	}
+	return nil
+}
+
+func fetchSource(ctx context.Context, source ScopeSource, previous *sourceEntry) (*sourceEntry, error) {
+	if conditional, ok := source.(conditionalSource); ok {
+		return conditional.fetchIfModified(ctx, previous)
+	}
+
+	services, err := source.FetchServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sourceEntry{FetchedAt: time.Now(), Services: *services}, nil
+}
+
+func MergeServices(all ...*GoogleServices) *GoogleServices {
+	merged := make(GoogleServices)
+	positions := make(map[string]map[string]int)
+
+	for _, services := range all {
+		if services == nil {
+			continue
+		}
+
+		for serviceName, scopes := range *services {
+			if positions[serviceName] == nil {
+				positions[serviceName] = make(map[string]int)
+			}
+
+			for _, scope := range scopes {
+				if index, exists := positions[serviceName][scope.URL]; exists {
+					if merged[serviceName][index].Description == "" {
+						merged[serviceName][index].Description = scope.Description
+					}
+					continue
+				}
+
+				positions[serviceName][scope.URL] = len(merged[serviceName])
+				merged[serviceName] = append(merged[serviceName], scope)
+			}
+		}
+	}
+
+	for serviceName, scopes := range merged {
+		sort.Slice(scopes, func(i, j int) bool {
+			return scopes[i].URL < scopes[j].URL
+		})
+		merged[serviceName] = scopes
+	}
+
+	return &merged
+}
@@ -0,0 +1,94 @@
+package googlescopes
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type staticSource struct {
+	name     string
+	services GoogleServices
+	err      error
+}
+
+func (s *staticSource) Name() string {
+	return s.name
+}
+
+func (s *staticSource) FetchServices(ctx context.Context) (*GoogleServices, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &s.services, nil
+}
+
+func TestMergeServices(t *testing.T) {
+	first := &GoogleServices{
+		"Drive API": {
+			{URL: "https://www.googleapis.com/auth/drive", Description: ""},
+			{URL: "https://www.googleapis.com/auth/drive.file", Description: "Per-file access"},
+		},
+	}
+	second := &GoogleServices{
+		"Drive API": {
+			{URL: "https://www.googleapis.com/auth/drive", Description: "Full access to Drive"},
+			{URL: "https://www.googleapis.com/auth/drive.appdata", Description: "App data"},
+		},
+		"Gmail API": {
+			{URL: "https://mail.google.com/", Description: "Full access to Gmail"},
+		},
+	}
+
+	merged := MergeServices(first, nil, second)
+
+	if merged.GetServiceCount() != 2 {
+		t.Errorf("Expected 2 services, got %d", merged.GetServiceCount())
+	}
+
+	drive, _ := merged.GetScopesForService("Drive API")
+	if len(drive) != 3 {
+		t.Fatalf("Expected 3 deduplicated Drive scopes, got %v", drive)
+	}
+	if drive[0].URL != "https://www.googleapis.com/auth/drive" || drive[0].Description != "Full access to Drive" {
+		t.Errorf("Expected the missing description to be filled in, got %+v", drive[0])
+	}
+	if drive[1].URL != "https://www.googleapis.com/auth/drive.appdata" {
+		t.Errorf("Expected scopes sorted by URL, got %v", drive)
+	}
+}
+
+func TestFetchCatalog_MultipleSources(t *testing.T) {
+	fake := newFakePlayground(t)
+	cachePath := filepath.Join(t.TempDir(), SCOPE_CACHE_FILE)
+	custom := &staticSource{
+		name: "custom",
+		services: GoogleServices{
+			"Internal API": {{URL: "https://example.com/auth/internal", Description: "Internal"}},
+			"Drive API":    {{URL: "https://www.googleapis.com/auth/drive", Description: "Duplicate"}},
+		},
+	}
+	sources := []ScopeSource{NewPlaygroundSource(NewClient().httpClient, fake.URL, "getScopes"), custom}
+
+	catalog := fetchCatalog(t, WithSources(sources...), WithCache(cachePath, time.Hour))
+	if catalog.Origin != ORIGIN_NETWORK {
+		t.Errorf("Expected a network fetch, got %s", catalog.Origin)
+	}
+	if !catalog.Services.HasService("Internal API") {
+		t.Errorf("Expected the custom source to be merged, got %v", catalog.Services.GetAllServiceNames())
+	}
+	if drive, _ := catalog.Services.GetScopesForService("Drive API"); len(drive) != 1 || drive[0].Description != "Full access to Drive" {
+		t.Errorf("Expected the first source to win on duplicates, got %v", drive)
+	}
+
+	custom.err = errors.New("unavailable")
+	catalog = fetchCatalog(t, WithSources(sources...), WithCache(cachePath, time.Hour), WithForceRefresh(true))
+	if catalog.Origin != ORIGIN_STALE_CACHE || catalog.FetchErr == nil {
+		t.Errorf("Expected a stale cache when one source fails, got %s (%v)", catalog.Origin, catalog.FetchErr)
+	}
+	if !catalog.Services.HasService("Internal API") {
+		t.Error("Expected the cached entry of the failing source to be kept")
+	}
+}
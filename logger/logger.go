@@ -11,6 +11,7 @@ type LogLevel int
 const (
 	LEVEL_SILENT LogLevel = iota
 	LEVEL_ERROR
+	LEVEL_WARN
 	LEVEL_INFO
 	LEVEL_DEBUG
 	LEVEL_VERBOSE
@@ -66,6 +67,12 @@ func Info(format string, args ...interface{}) {
 	}
 }
 
+func Warn(format string, args ...interface{}) {
+	if globalLogger.level >= LEVEL_WARN {
+		log.Printf(globalLogger.prefix+"[WARN] "+format, args...)
+	}
+}
+
 func Error(format string, args ...interface{}) {
 	if globalLogger.level >= LEVEL_ERROR {
 		log.Printf(globalLogger.prefix+"[ERROR] "+format, args...)
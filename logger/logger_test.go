@@ -143,6 +143,7 @@ func TestLogFunctions(t *testing.T) {
 	SetLevel(LEVEL_DEBUG)
 	Debug("Test debug message")
 	Info("Test info message")
+	Warn("Test warn message")
 	Error("Test error message")
 	Print("Test print message")
 	Println("Test println message")
@@ -150,6 +151,7 @@ func TestLogFunctions(t *testing.T) {
 	SetLevel(LEVEL_SILENT)
 	Debug("This should not appear")
 	Info("This should not appear")
+	Warn("This should not appear")
 	Print("This should not appear")
 	Println("This should not appear")
 }
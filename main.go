@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"google-auth-wizard/auth"
 	"google-auth-wizard/config"
@@ -9,130 +11,396 @@ import (
 	"google-auth-wizard/storage"
 	"google-auth-wizard/terminal"
 	"google-auth-wizard/utils"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/goforj/godump"
 	"golang.org/x/oauth2"
 )
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+	switch command(os.Args) {
+	case "revoke":
+		err = runRevoke(os.Args[2:])
+	case "profiles":
+		err = runProfiles(os.Args[2:])
+	case "export-adc":
+		err = runExportADC(os.Args[2:])
+	case "token":
+		err = runToken(os.Args[2:])
+	case "exec":
+		err = runExec(os.Args[2:])
+	case "serve-metadata":
+		err = runServeMetadata(os.Args[2:])
+	case "proxy":
+		err = runProxy(os.Args[2:])
+	case "scopes":
+		err = runScopes(os.Args[2:])
+	default:
+		err = run()
+	}
+
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		if exitErr.err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", exitErr.err)
+		}
+		os.Exit(exitErr.code)
+	}
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+func command(args []string) string {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		return ""
+	}
+	return args[1]
+}
+
 func run() error {
 	logger.Debug("Starting Google Auth Wizard")
 
 	cfg := config.LoadConfigWithDefaults("config.yaml")
 	logger.Debug("Configuration loaded: %+v", cfg)
 
-	filename := utils.ParseFlags()
-	logger.Debug("Using credentials file: %s", filename)
+	flags := utils.ParseFlags()
+	if flags.ClearTokens {
+		return clearProfile(cfg, flags.Profile)
+	}
+	logger.Debug("Using credentials file: %s", flags.Filename)
+
+	if flags.Flow != "" {
+		if !config.IsValidFlow(flags.Flow) {
+			return fmt.Errorf("invalid flow %q: must be %s, %s or %s", flags.Flow, config.FLOW_LOOPBACK, config.FLOW_DEVICE, config.FLOW_MANUAL)
+		}
+		cfg.OAuth.Flow = flags.Flow
+	}
+
+	credentials := utils.ReadCredentials(flags.Filename)
+
+	selectedScopes, err := selectScopes(cfg, flags)
+	if err != nil {
+		return err
+	}
+	if selectedScopes == nil {
+		return nil
+	}
+
+	if err := printSelectedScopes(cfg, selectedScopes); err != nil {
+		return err
+	}
+	if len(selectedScopes) == 0 {
+		return fmt.Errorf("no OAuth scopes selected. Please run the application again and select at least one scope to proceed with authentication")
+	}
+
+	logger.Info("Creating OAuth configuration...")
+	config, err := auth.CreateOAuthConfig(credentials, selectedScopes)
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth config: %w", err)
+	}
+
+	logger.Info("Starting OAuth flow...")
+
+	tokenStorage, profile, err := openProfile(cfg, flags.Profile)
+	if err != nil {
+		return err
+	}
+	forceNew := os.Getenv("GOOGLE_AUTH_WIZARD_FORCE_NEW") == "true"
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var token *oauth2.Token
+	if !forceNew && tokenStorage.Exists() {
+		logger.Debug("Found existing token file, checking validity...")
+		token, err = loadStoredToken(ctx, tokenStorage, profile, config, selectedScopes)
+		if err != nil {
+			return err
+		}
+	} else if forceNew {
+		if err := tokenStorage.CheckWritable(); err != nil {
+			return err
+		}
+		logger.Debug("Force new token requested, ignoring saved tokens")
+	}
+
+	if token == nil {
+		logger.Info("Obtaining new OAuth token...")
+		newToken, err := auth.NewAuthenticator(cfg, config).Token(ctx)
+		if err != nil {
+			var authErr *auth.AuthorizationError
+			if errors.As(err, &authErr) {
+				return fmt.Errorf("failed to get OAuth token: %w\n%s", err, authorizationErrorHint(authErr))
+			}
+			return fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		token = newToken
 
-	credentials := utils.ReadCredentials(filename)
-	googleServices, err := fetchGoogleScopes(cfg)
+		owner := storage.Owner{
+			ClientID:  config.ClientID,
+			ProjectID: auth.ProjectIDFromCredentials(credentials),
+			Email:     auth.AccountEmail(ctx, cfg.OAuth.UserInfoURL, token),
+		}
+		switch {
+		case owner.Email != "":
+			logger.Info("Authorized as %s", owner.Email)
+		case !auth.GrantsEmail(selectedScopes):
+			logger.Warn("No account email recorded for profile %q: select the openid and email scopes to record it", profile)
+		default:
+			logger.Warn("Unable to read the account email for profile %q", profile)
+		}
+
+		if err := saveToken(tokenStorage, token, selectedScopes, owner); err != nil {
+			logger.Error("Failed to save token: %v", err)
+		} else {
+			logger.Info("Token saved to profile %q", profile)
+		}
+	}
+
+	logger.Info("OAuth token received successfully!")
+	if logger.IsDebug() {
+		godump.Dump(token)
+	} else {
+		logger.Print("Access token: %s\n", token.AccessToken[:10]+"...")
+	}
+	return nil
+}
+
+func selectScopes(cfg *config.Config, flags *utils.Flags) ([]string, error) {
+	if flags.HasScopes() {
+		return requestedScopes(cfg, flags)
+	}
+
+	catalog, err := fetchGoogleScopes(cfg, flags.RefreshScopes)
 	if err != nil {
-		return fmt.Errorf("failed to fetch Google scopes: %w", err)
+		return nil, fmt.Errorf("failed to fetch Google scopes: %w", err)
 	}
 
-	terminal := createTerminal(cfg)
-	items := convertToTerminalItems(googleServices)
+	terminal := createTerminal(cfg, catalogNotice(catalog))
+	items := convertToTerminalItems(catalog.Services)
 
 	logger.Debug("Created %d terminal items for user selection", len(items))
 	logger.Info("Starting scope selection interface...")
 
 	selectedScopes, err := terminal.Run("Select Google Scopes OAuth 2.0", items)
 	if err != nil {
-		return fmt.Errorf("terminal error: %w", err)
+		return nil, fmt.Errorf("terminal error: %w", err)
 	}
 
 	logger.Debug("User selected %d scopes", len(selectedScopes))
 
-	if terminal.HasBeenValidated() {
-		printSelectedScopes(selectedScopes)
-		if len(selectedScopes) == 0 {
-			return fmt.Errorf("no OAuth scopes selected. Please run the application again and select at least one scope to proceed with authentication")
-		}
+	if !terminal.HasBeenValidated() {
+		return nil, nil
+	}
+	if selectedScopes == nil {
+		selectedScopes = []string{}
+	}
+	return selectedScopes, nil
+}
 
-		logger.Info("Creating OAuth configuration...")
-		config, err := auth.CreateOAuthConfig(credentials, selectedScopes)
+func requestedScopes(cfg *config.Config, flags *utils.Flags) ([]string, error) {
+	scopes := flags.Scopes
+	if flags.ScopesFile != "" {
+		fileScopes, err := utils.ReadScopesFile(flags.ScopesFile)
 		if err != nil {
-			return fmt.Errorf("failed to create OAuth config: %w", err)
-		}
-
-		logger.Info("Starting OAuth flow...")
-
-		tokenStorage := storage.NewTokenStorage(storage.GetDefaultTokenPath())
-		forceNew := os.Getenv("GOOGLE_AUTH_WIZARD_FORCE_NEW") == "true"
-
-		var token *oauth2.Token
-		if !forceNew && tokenStorage.Exists() {
-			logger.Debug("Found existing token file, checking validity...")
-			if storedToken, err := tokenStorage.Load(); err == nil {
-				if storedToken.IsValid() && storedToken.HasScopes(selectedScopes) {
-					logger.Info("Using existing valid token")
-					token = storedToken.Token
-				} else {
-					logger.Debug("Stored token is invalid or missing required scopes")
-				}
-			} else {
-				logger.Debug("Failed to load stored token: %v", err)
-			}
-		} else if forceNew {
-			logger.Debug("Force new token requested, ignoring saved tokens")
+			return nil, err
 		}
+		scopes = append(scopes, fileScopes...)
+	}
+	scopes = utils.ParseScopeList(scopes...)
 
-		if token == nil {
-			logger.Info("Obtaining new OAuth token...")
-			newToken, err := auth.GetTokenFromLocalServer(cfg, config)
-			if err != nil {
-				return fmt.Errorf("failed to get OAuth token: %w", err)
-			}
-			token = newToken
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("no OAuth scopes given with -scopes or -scopes-file")
+	}
 
-			if err := tokenStorage.Save(token, selectedScopes); err != nil {
-				logger.Error("Failed to save token: %v", err)
-			} else {
-				logger.Info("Token saved to %s", storage.GetDefaultTokenPath())
-			}
+	catalog, err := fetchGoogleScopes(cfg, flags.RefreshScopes)
+	if err != nil {
+		if flags.StrictScopes {
+			return nil, fmt.Errorf("failed to fetch Google scopes for validation: %w", err)
 		}
+		logger.Warn("Unable to validate requested scopes: %v", err)
+		return scopes, nil
+	}
 
-		logger.Info("OAuth token received successfully!")
-		if logger.IsDebug() {
-			godump.Dump(token)
-		} else {
-			logger.Print("Access token: %s\n", token.AccessToken[:10]+"...")
+	if unknown := catalog.Services.UnknownScopes(scopes); len(unknown) > 0 {
+		if flags.StrictScopes {
+			return nil, fmt.Errorf("unknown OAuth scopes: %s", strings.Join(unknown, ", "))
 		}
+		logger.Warn("Unknown OAuth scopes (requesting them anyway): %s", strings.Join(unknown, ", "))
 	}
-	return nil
+
+	return scopes, nil
 }
 
-func fetchGoogleScopes(cfg *config.Config) (*googlescopes.GoogleServices, error) {
-	logger.Debug("Fetching Google scopes from %s", cfg.OAuth.OAuthPlaygroundURL)
+func loadStoredToken(ctx context.Context, tokenStorage *storage.TokenStorage, profile string, oauthConfig *oauth2.Config, selectedScopes []string) (*oauth2.Token, error) {
+	unlock, err := tokenStorage.Lock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock profile %q: %w", profile, err)
+	}
+	defer unlock()
 
-	client := googlescopes.NewClient(
-		googlescopes.WithTimeout(cfg.OAuth.ScopeTimeout),
-		googlescopes.WithBaseURL(cfg.OAuth.OAuthPlaygroundURL),
-		googlescopes.WithScopeEndpoint(cfg.OAuth.ScopeEndpoint),
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		var versionErr *storage.UnsupportedVersionError
+		if errors.As(err, &versionErr) || errors.Is(err, storage.ErrWrongPassphrase) {
+			return nil, fmt.Errorf("failed to load profile %q: %w", profile, err)
+		}
+		logger.Debug("Failed to load stored token: %v", err)
+		return nil, nil
+	}
+
+	legacy := storedToken.IsLegacy()
+	if !legacy && !storedToken.BelongsTo(oauthConfig.ClientID) {
+		return nil, fmt.Errorf("profile %q holds a token for client %s, but the credentials file uses client %s; use -profile to choose another profile or -n to replace it",
+			profile, storedToken.ClientID, oauthConfig.ClientID)
+	}
+
+	if !storedToken.HasScopes(selectedScopes) {
+		logger.Debug("Stored token is missing required scopes")
+		return nil, nil
+	}
+
+	if storedToken.IsValid() && !legacy {
+		logger.Info("Using existing valid token")
+		return storedToken.Token, nil
+	}
+
+	if !storedToken.CanRefresh() {
+		if legacy {
+			logger.Warn("Legacy token in profile %q does not record its OAuth client and has no refresh token; it must be re-authorized", profile)
+			return nil, nil
+		}
+		logger.Debug("Stored token is expired and has no refresh token")
+		return nil, nil
+	}
+
+	if legacy {
+		logger.Info("Legacy token does not record its OAuth client, refreshing it with client %s...", oauthConfig.ClientID)
+	} else {
+		logger.Info("Stored token expired, refreshing...")
+	}
+	token, err := auth.RefreshToken(ctx, oauthConfig, storedToken.Token)
+	if err != nil {
+		if legacy {
+			logger.Warn("Legacy token in profile %q could not be refreshed with client %s; it must be re-authorized: %v", profile, oauthConfig.ClientID, err)
+			return nil, nil
+		}
+		if auth.IsInvalidGrant(err) {
+			logger.Info("Refresh token was revoked or has expired, consent is required again")
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	owner := storedToken.Owner()
+	if legacy {
+		owner.ClientID = oauthConfig.ClientID
+	}
+
+	if err := tokenStorage.Save(token, storedToken.Scopes, owner); err != nil {
+		logger.Error("Failed to save refreshed token: %v", err)
+	} else {
+		logger.Info("Refreshed token saved to profile %q", profile)
+	}
+
+	return token, nil
+}
+
+func saveToken(tokenStorage *storage.TokenStorage, token *oauth2.Token, scopes []string, owner storage.Owner) error {
+	unlock, err := tokenStorage.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return tokenStorage.Save(token, scopes, owner)
+}
+
+func fetchGoogleScopes(cfg *config.Config, refresh bool) (*googlescopes.Catalog, error) {
+	logger.Debug("Fetching Google scopes from %d source(s)", max(len(cfg.Scopes.Sources), 1))
+	if cfg.OAuth.ScopeCacheTTL == 0 && !refresh {
+		logger.Info("oauth.scopeCacheTTL is 0: the cached scope catalog is revalidated on every run")
+	}
+
+	client := newScopeClient(cfg,
+		googlescopes.WithForceRefresh(refresh),
+		googlescopes.WithSnapshotFallback(true),
 	)
 
-	googleServices, err := client.FetchScopes()
+	catalog, err := client.FetchCatalog(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("error fetching scopes: %w", err)
 	}
 
+	switch catalog.Origin {
+	case googlescopes.ORIGIN_CACHE:
+		logger.Debug("Using scope catalog cached at %s", catalog.FetchedAt.Format("2006-01-02 15:04:05"))
+	case googlescopes.ORIGIN_STALE_CACHE:
+		logger.Warn("Unable to fetch scopes (%v), using the catalog cached at %s", catalog.FetchErr, catalog.FetchedAt.Format("2006-01-02 15:04:05"))
+	case googlescopes.ORIGIN_SNAPSHOT:
+		logger.Warn("Unable to fetch scopes (%v), using the offline snapshot from %s", catalog.FetchErr, googlescopes.SNAPSHOT_DATE)
+	}
+
 	logger.Debug("Fetched %d Google services with %d total scopes",
-		googleServices.GetServiceCount(), googleServices.GetTotalScopeCount())
+		catalog.Services.GetServiceCount(), catalog.Services.GetTotalScopeCount())
+
+	return catalog, nil
+}
+
+func newScopeClient(cfg *config.Config, options ...googlescopes.ClientOption) *googlescopes.Client {
+	return googlescopes.NewClient(append([]googlescopes.ClientOption{
+		googlescopes.WithTimeout(cfg.OAuth.ScopeTimeout),
+		googlescopes.WithBaseURL(cfg.OAuth.OAuthPlaygroundURL),
+		googlescopes.WithScopeEndpoint(cfg.OAuth.ScopeEndpoint),
+		googlescopes.WithSources(scopeSources(cfg)...),
+		googlescopes.WithCache(filepath.Join(storageDir(cfg), googlescopes.SCOPE_CACHE_FILE), cfg.OAuth.ScopeCacheTTL),
+		googlescopes.WithSensitivityOverrides(cfg.Scopes.Sensitivity),
+	}, options...)...)
+}
+
+func scopeSources(cfg *config.Config) []googlescopes.ScopeSource {
+	httpClient := &http.Client{Timeout: cfg.OAuth.ScopeTimeout}
+
+	sources := make([]googlescopes.ScopeSource, 0, len(cfg.Scopes.Sources))
+	for _, source := range cfg.Scopes.Sources {
+		switch source.Type {
+		case config.SCOPE_SOURCE_PLAYGROUND:
+			baseURL := utils.Ternary(source.URL != "", source.URL, cfg.OAuth.OAuthPlaygroundURL)
+			sources = append(sources, googlescopes.NewPlaygroundSource(httpClient, baseURL, cfg.OAuth.ScopeEndpoint))
+		case config.SCOPE_SOURCE_FILE:
+			sources = append(sources, googlescopes.NewFileSource(source.Path))
+		case config.SCOPE_SOURCE_DISCOVERY:
+			sources = append(sources, googlescopes.NewDiscoverySource(httpClient, source.URL, source.APIs))
+		}
+	}
+
+	return sources
+}
 
-	return googleServices, nil
+func catalogNotice(catalog *googlescopes.Catalog) string {
+	switch catalog.Origin {
+	case googlescopes.ORIGIN_STALE_CACHE:
+		return fmt.Sprintf("⚠ Offline: showing the scope catalog cached on %s", catalog.FetchedAt.Format("2006-01-02"))
+	case googlescopes.ORIGIN_SNAPSHOT:
+		return fmt.Sprintf("⚠ Offline: showing the built-in scope snapshot from %s, some scopes may be missing", googlescopes.SNAPSHOT_DATE)
+	default:
+		return ""
+	}
 }
 
-func createTerminal(cfg *config.Config) *terminal.Terminal {
+func createTerminal(cfg *config.Config, notice string) *terminal.Terminal {
 	return terminal.New(
 		terminal.WithListHeight(cfg.Terminal.Height),
+		terminal.WithNotice(notice),
 		terminal.WithTitleStyle(terminal.DefaultTitleStyle().
 			Foreground(terminal.Color("#FFFFFF")).
 			Bold(true),
@@ -144,11 +412,61 @@ func createTerminal(cfg *config.Config) *terminal.Terminal {
 	)
 }
 
-func printSelectedScopes(selectedScopes []string) {
+func authorizationErrorHint(authErr *auth.AuthorizationError) string {
+	var hint string
+	switch authErr.Code {
+	case "access_denied":
+		hint = "The consent screen was cancelled. Run the wizard again and click \"Allow\"; if the app is in testing mode, make sure your account is listed as a test user."
+	case "invalid_scope":
+		hint = "One of the selected scopes is not valid for this client. Deselect it or enable the corresponding API in your Google Cloud project."
+	case "admin_policy_enforced":
+		hint = "Your Google Workspace administrator blocks these scopes for this app. Ask them to trust the OAuth client or select fewer scopes."
+	case "org_internal":
+		hint = "The consent screen is set to Internal. Sign in with an account from the owning organization or switch the user type to External."
+	case "unauthorized_client", "invalid_client":
+		hint = "Check that the credentials file belongs to a Desktop application OAuth client (or a TV and Limited Input client for -flow device)."
+	case "disallowed_useragent":
+		hint = "Open the authorization URL in a regular browser, not an embedded web view."
+	default:
+		hint = "See https://developers.google.com/identity/protocols/oauth2/native-app#handlingresponse for details."
+	}
+
+	if authErr.URI != "" {
+		hint += " More information: " + authErr.URI
+	}
+	return hint
+}
+
+func printSelectedScopes(cfg *config.Config, selectedScopes []string) error {
+	table, err := googlescopes.NewSensitivityTable(cfg.Scopes.Sensitivity)
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("\nSelected scopes (%d):\n", len(selectedScopes))
 	for _, scope := range selectedScopes {
-		fmt.Printf("- %s\n", scope)
+		switch level := table.Level(scope); level {
+		case googlescopes.SENSITIVITY_RESTRICTED, googlescopes.SENSITIVITY_SENSITIVE:
+			fmt.Printf("- %s [%s]\n", scope, level)
+		case googlescopes.SENSITIVITY_UNKNOWN:
+			fmt.Printf("- %s [unclassified]\n", scope)
+		default:
+			fmt.Printf("- %s\n", scope)
+		}
+	}
+
+	restricted, sensitive, unknown := table.Count(selectedScopes)
+	if restricted > 0 || sensitive > 0 {
+		fmt.Printf("\n⚠ %d restricted and %d sensitive scopes: publishing the app requires Google verification", restricted, sensitive)
+		if restricted > 0 {
+			fmt.Print(" and a security assessment")
+		}
+		fmt.Println()
 	}
+	if unknown > 0 {
+		fmt.Printf("%d unclassified scopes are missing from the sensitivity table and were not checked\n", unknown)
+	}
+	return nil
 }
 
 func convertToTerminalItems(services *googlescopes.GoogleServices) []terminal.Item {
@@ -165,6 +483,7 @@ func convertToTerminalItems(services *googlescopes.GoogleServices) []terminal.It
 				Title:       scope.URL,
 				Description: scope.Description,
 				Value:       scope.URL,
+				Sensitivity: scope.Sensitivity,
 				IsHeader:    false,
 			}
 		}
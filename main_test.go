@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"google-auth-wizard/config"
+	"google-auth-wizard/storage"
+	"google-auth-wizard/utils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID     = "test-client-id"
+	testRefreshToken = "test-refresh-token"
+)
+
+type fakeTokenServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	refreshes int
+}
+
+func newFakeTokenServer(t *testing.T) *fakeTokenServer {
+	f := &fakeTokenServer{}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handleToken))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeTokenServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "refresh_token" {
+		writeFakeTokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	if r.PostForm.Get("client_id") != testClientID {
+		writeFakeTokenError(w, "unauthorized_client")
+		return
+	}
+
+	if r.PostForm.Get("refresh_token") != testRefreshToken {
+		writeFakeTokenError(w, "invalid_grant")
+		return
+	}
+
+	f.mu.Lock()
+	f.refreshes++
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "refreshed-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+func writeFakeTokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func (f *fakeTokenServer) refreshCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refreshes
+}
+
+func (f *fakeTokenServer) oauthConfig(clientID string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: "test-client-secret",
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  f.URL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+func expiredToken() *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  "expired-access-token",
+		TokenType:    "Bearer",
+		RefreshToken: testRefreshToken,
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+}
+
+func migrateLegacyToken(t *testing.T, token *oauth2.Token, scopes []string) *storage.TokenStorage {
+	t.Helper()
+
+	dir := t.TempDir()
+	data, err := json.Marshal(map[string]interface{}{"token": token, "scopes": scopes})
+	if err != nil {
+		t.Fatalf("Failed to marshal legacy token: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, storage.LEGACY_TOKEN_FILE), data, 0600); err != nil {
+		t.Fatalf("Failed to write legacy token: %v", err)
+	}
+
+	profileStore := storage.NewProfileStore(dir, storage.NewFileTokenStore(dir))
+	if migrated, err := profileStore.MigrateLegacyToken(); err != nil || !migrated {
+		t.Fatalf("Expected legacy token to be migrated, got migrated=%v err=%v", migrated, err)
+	}
+
+	tokenStorage, err := profileStore.TokenStorage(storage.DEFAULT_PROFILE)
+	if err != nil {
+		t.Fatalf("Failed to open default profile: %v", err)
+	}
+	return tokenStorage
+}
+
+func TestLoadStoredToken_MigratedLegacyToken(t *testing.T) {
+	server := newFakeTokenServer(t)
+	scopes := []string{"openid"}
+	tokenStorage := migrateLegacyToken(t, expiredToken(), scopes)
+
+	token, err := loadStoredToken(context.Background(), tokenStorage, storage.DEFAULT_PROFILE, server.oauthConfig(testClientID), scopes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token == nil || token.AccessToken != "refreshed-access-token" || server.refreshCount() != 1 {
+		t.Fatalf("Expected the migrated token to be refreshed once, got %+v", token)
+	}
+
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		t.Fatalf("Expected to load the profile, got %v", err)
+	}
+	if !storedToken.BelongsTo(testClientID) {
+		t.Errorf("Expected the migrated token to adopt client %s, got %q", testClientID, storedToken.ClientID)
+	}
+
+	if _, err := loadStoredToken(context.Background(), tokenStorage, storage.DEFAULT_PROFILE, server.oauthConfig("other-client-id"), scopes); err == nil {
+		t.Error("Expected the adopted token to be bound to its client")
+	}
+}
+
+func TestLoadStoredToken_MigratedLegacyTokenOtherClient(t *testing.T) {
+	server := newFakeTokenServer(t)
+	scopes := []string{"openid"}
+	tokenStorage := migrateLegacyToken(t, expiredToken(), scopes)
+
+	token, err := loadStoredToken(context.Background(), tokenStorage, storage.DEFAULT_PROFILE, server.oauthConfig("other-client-id"), scopes)
+	if err != nil || token != nil {
+		t.Fatalf("Expected re-authorization to be required, got token=%v err=%v", token, err)
+	}
+
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		t.Fatalf("Expected to load the profile, got %v", err)
+	}
+	if !storedToken.IsLegacy() {
+		t.Errorf("Expected the legacy token to be left unbound, got client %q", storedToken.ClientID)
+	}
+}
+
+func TestLoadStoredToken_RefreshesExpiredToken(t *testing.T) {
+	server := newFakeTokenServer(t)
+	scopes := []string{"https://www.googleapis.com/auth/drive.readonly"}
+	tokenStorage := storage.NewTokenStorage(storage.NewFileTokenStore(t.TempDir()), "work")
+
+	owner := storage.Owner{ClientID: testClientID, ProjectID: "test-project", Email: "user@example.com"}
+	if err := tokenStorage.Save(expiredToken(), scopes, owner); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	token, err := loadStoredToken(context.Background(), tokenStorage, "work", server.oauthConfig(testClientID), scopes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token == nil || token.AccessToken != "refreshed-access-token" {
+		t.Fatalf("Expected the refreshed token, got %+v", token)
+	}
+
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		t.Fatalf("Expected to load the profile, got %v", err)
+	}
+	if storedToken.Token.AccessToken != "refreshed-access-token" || !storedToken.IsValid() {
+		t.Errorf("Expected the refreshed token to be saved, got %+v", storedToken.Token)
+	}
+	if storedToken.Token.RefreshToken != testRefreshToken {
+		t.Errorf("Expected the refresh token to be kept, got %q", storedToken.Token.RefreshToken)
+	}
+	if storedToken.Owner() != owner {
+		t.Errorf("Expected the owner to be kept, got %+v", storedToken.Owner())
+	}
+	if len(storedToken.Scopes) != 1 || storedToken.Scopes[0] != scopes[0] {
+		t.Errorf("Expected the scopes to be kept, got %v", storedToken.Scopes)
+	}
+
+	if _, err := loadStoredToken(context.Background(), tokenStorage, "work", server.oauthConfig(testClientID), scopes); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refreshes := server.refreshCount(); refreshes != 1 {
+		t.Errorf("Expected the saved token to be reused without refreshing, got %d refreshes", refreshes)
+	}
+}
+
+func TestLoadStoredToken_RevokedRefreshToken(t *testing.T) {
+	server := newFakeTokenServer(t)
+	scopes := []string{"openid"}
+	tokenStorage := storage.NewTokenStorage(storage.NewFileTokenStore(t.TempDir()), "work")
+
+	token := expiredToken()
+	token.RefreshToken = "revoked-refresh-token"
+	if err := tokenStorage.Save(token, scopes, storage.Owner{ClientID: testClientID}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	refreshed, err := loadStoredToken(context.Background(), tokenStorage, "work", server.oauthConfig(testClientID), scopes)
+	if err != nil || refreshed != nil {
+		t.Errorf("Expected consent to be required again, got token=%v err=%v", refreshed, err)
+	}
+}
+
+func TestRequestedScopes(t *testing.T) {
+	dir := t.TempDir()
+	catalogFile := filepath.Join(dir, "catalog.yaml")
+	catalog := `Drive API:
+  - url: https://www.googleapis.com/auth/drive.readonly
+    description: Read-only Drive access
+  - url: https://www.googleapis.com/auth/drive.file
+    description: Per-file Drive access
+OpenID:
+  - url: openid
+    description: OpenID Connect
+`
+	if err := os.WriteFile(catalogFile, []byte(catalog), 0600); err != nil {
+		t.Fatalf("Failed to write scope catalog: %v", err)
+	}
+
+	scopesFile := filepath.Join(dir, "scopes.txt")
+	scopesContent := "# CI scopes\nhttps://www.googleapis.com/auth/drive.file\nopenid, https://www.googleapis.com/auth/drive.readonly # duplicate of -scopes\n"
+	if err := os.WriteFile(scopesFile, []byte(scopesContent), 0600); err != nil {
+		t.Fatalf("Failed to write scopes file: %v", err)
+	}
+
+	emptyFile := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(emptyFile, []byte("# nothing\n"), 0600); err != nil {
+		t.Fatalf("Failed to write empty scopes file: %v", err)
+	}
+
+	cfg := config.GetDefaultConfig()
+	cfg.Storage.Directory = dir
+	cfg.Scopes.Sources = []config.ScopeSourceConfig{{Type: config.SCOPE_SOURCE_FILE, Path: catalogFile}}
+
+	tests := []struct {
+		name     string
+		flags    utils.Flags
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "flag scopes",
+			flags:    utils.Flags{Scopes: []string{"openid", "https://www.googleapis.com/auth/drive.readonly", "openid"}},
+			expected: []string{"openid", "https://www.googleapis.com/auth/drive.readonly"},
+		},
+		{
+			name:     "scopes file",
+			flags:    utils.Flags{ScopesFile: scopesFile},
+			expected: []string{"https://www.googleapis.com/auth/drive.file", "openid", "https://www.googleapis.com/auth/drive.readonly"},
+		},
+		{
+			name:     "flags first then file without duplicates",
+			flags:    utils.Flags{Scopes: []string{"https://www.googleapis.com/auth/drive.readonly"}, ScopesFile: scopesFile},
+			expected: []string{"https://www.googleapis.com/auth/drive.readonly", "https://www.googleapis.com/auth/drive.file", "openid"},
+		},
+		{
+			name:     "unknown scope is kept with a warning",
+			flags:    utils.Flags{Scopes: []string{"openid", "https://example.com/auth/unknown"}},
+			expected: []string{"openid", "https://example.com/auth/unknown"},
+		},
+		{
+			name:    "unknown scope fails in strict mode",
+			flags:   utils.Flags{Scopes: []string{"openid", "https://example.com/auth/unknown"}, StrictScopes: true},
+			wantErr: true,
+		},
+		{
+			name:    "missing scopes file",
+			flags:   utils.Flags{ScopesFile: filepath.Join(dir, "missing.txt")},
+			wantErr: true,
+		},
+		{
+			name:    "no scopes",
+			flags:   utils.Flags{ScopesFile: emptyFile},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := requestedScopes(cfg, &tt.flags)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got scopes %v", scopes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !slices.Equal(scopes, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, scopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package metadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	METADATA_FLAVOR_HEADER = "Metadata-Flavor"
+	METADATA_FLAVOR_GOOGLE = "Google"
+
+	SERVICE_ACCOUNTS_PATH = "/computeMetadata/v1/instance/service-accounts/"
+	PROJECT_ID_PATH       = "/computeMetadata/v1/project/project-id"
+	DEFAULT_ACCOUNT       = "default"
+
+	DEFAULT_EXPIRES_IN = 3600
+)
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+type Server struct {
+	tokenSource oauth2.TokenSource
+	email       string
+	scopes      []string
+	projectID   string
+	mux         *http.ServeMux
+}
+
+type ServerOption func(*Server)
+
+func WithEmail(email string) ServerOption {
+	return func(s *Server) {
+		s.email = email
+	}
+}
+
+func WithScopes(scopes []string) ServerOption {
+	return func(s *Server) {
+		s.scopes = scopes
+	}
+}
+
+func WithProjectID(projectID string) ServerOption {
+	return func(s *Server) {
+		s.projectID = projectID
+	}
+}
+
+func NewServer(tokenSource oauth2.TokenSource, options ...ServerOption) *Server {
+	server := &Server{
+		tokenSource: tokenSource,
+		mux:         http.NewServeMux(),
+	}
+
+	for _, option := range options {
+		option(server)
+	}
+
+	server.mux.HandleFunc("GET /{$}", server.handlePing)
+	server.mux.HandleFunc("GET "+SERVICE_ACCOUNTS_PATH+"{account}/token", server.handleToken)
+	server.mux.HandleFunc("GET "+SERVICE_ACCOUNTS_PATH+"{account}/email", server.handleEmail)
+	server.mux.HandleFunc("GET "+SERVICE_ACCOUNTS_PATH+"{account}/scopes", server.handleScopes)
+	server.mux.HandleFunc("GET "+PROJECT_ID_PATH, server.handleProjectID)
+
+	return server
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(METADATA_FLAVOR_HEADER, METADATA_FLAVOR_GOOGLE)
+
+	if r.Header.Get("X-Forwarded-For") != "" {
+		http.Error(w, "requests through a proxy are not allowed", http.StatusForbidden)
+		return
+	}
+
+	if r.URL.Path != "/" && r.Header.Get(METADATA_FLAVOR_HEADER) != METADATA_FLAVOR_GOOGLE {
+		http.Error(w, "missing Metadata-Flavor:Google header", http.StatusForbidden)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte("computeMetadata/\n"))
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if !s.isKnownAccount(r.PathValue("account")) {
+		http.NotFound(w, r)
+		return
+	}
+
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		http.Error(w, "failed to get token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	expiresIn := DEFAULT_EXPIRES_IN
+	if !token.Expiry.IsZero() {
+		expiresIn = int(time.Until(token.Expiry).Seconds())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken: token.AccessToken,
+		ExpiresIn:   expiresIn,
+		TokenType:   token.Type(),
+	})
+}
+
+func (s *Server) handleEmail(w http.ResponseWriter, r *http.Request) {
+	if !s.isKnownAccount(r.PathValue("account")) || s.email == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(s.email))
+}
+
+func (s *Server) handleScopes(w http.ResponseWriter, r *http.Request) {
+	if !s.isKnownAccount(r.PathValue("account")) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(strings.Join(s.scopes, "\n") + "\n"))
+}
+
+func (s *Server) handleProjectID(w http.ResponseWriter, r *http.Request) {
+	if s.projectID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(s.projectID))
+}
+
+func (s *Server) isKnownAccount(account string) bool {
+	return account == DEFAULT_ACCOUNT || (s.email != "" && account == s.email)
+}
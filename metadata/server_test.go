@@ -0,0 +1,163 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type countingTokenSource struct {
+	calls int
+	err   error
+}
+
+func (c *countingTokenSource) Token() (*oauth2.Token, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &oauth2.Token{
+		AccessToken: "metadata-access-token",
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(30 * time.Minute),
+	}, nil
+}
+
+func newTestServer(t *testing.T, tokenSource oauth2.TokenSource) *httptest.Server {
+	server := httptest.NewServer(NewServer(tokenSource,
+		WithEmail("user@example.com"),
+		WithScopes([]string{"openid", "https://www.googleapis.com/auth/cloud-platform"}),
+		WithProjectID("my-project"),
+	))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return resp, string(body)
+}
+
+func metadataHeader() http.Header {
+	return http.Header{METADATA_FLAVOR_HEADER: {METADATA_FLAVOR_GOOGLE}}
+}
+
+func TestServer_Token(t *testing.T) {
+	tokenSource := &countingTokenSource{}
+	server := newTestServer(t, tokenSource)
+
+	resp, body := get(t, server.URL+SERVICE_ACCOUNTS_PATH+"default/token", metadataHeader())
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get(METADATA_FLAVOR_HEADER) != METADATA_FLAVOR_GOOGLE {
+		t.Error("Expected the Metadata-Flavor response header")
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal([]byte(body), &token); err != nil {
+		t.Fatalf("Failed to decode token response: %v", err)
+	}
+	if token.AccessToken != "metadata-access-token" || token.TokenType != "Bearer" {
+		t.Errorf("Unexpected token response: %+v", token)
+	}
+	if token.ExpiresIn <= 0 || token.ExpiresIn > 1800 {
+		t.Errorf("Expected expires_in within 30 minutes, got %d", token.ExpiresIn)
+	}
+
+	resp, _ = get(t, server.URL+SERVICE_ACCOUNTS_PATH+"user@example.com/token", metadataHeader())
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the account email to be accepted, got %d", resp.StatusCode)
+	}
+	if tokenSource.calls != 2 {
+		t.Errorf("Expected the token source to be asked on every request, got %d calls", tokenSource.calls)
+	}
+}
+
+func TestServer_RequiresMetadataFlavor(t *testing.T) {
+	tokenSource := &countingTokenSource{}
+	server := newTestServer(t, tokenSource)
+
+	resp, _ := get(t, server.URL+SERVICE_ACCOUNTS_PATH+"default/token", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 without the Metadata-Flavor header, got %d", resp.StatusCode)
+	}
+
+	header := metadataHeader()
+	header.Set("X-Forwarded-For", "203.0.113.1")
+	resp, _ = get(t, server.URL+SERVICE_ACCOUNTS_PATH+"default/token", header)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a forwarded request, got %d", resp.StatusCode)
+	}
+
+	if tokenSource.calls != 0 {
+		t.Errorf("Expected no token to be issued, got %d calls", tokenSource.calls)
+	}
+
+	resp, _ = get(t, server.URL+"/", nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(METADATA_FLAVOR_HEADER) != METADATA_FLAVOR_GOOGLE {
+		t.Errorf("Expected the root ping to succeed without the header, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_EmailScopesAndProject(t *testing.T) {
+	server := newTestServer(t, &countingTokenSource{})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{SERVICE_ACCOUNTS_PATH + "default/email", "user@example.com"},
+		{SERVICE_ACCOUNTS_PATH + "default/scopes", "openid\nhttps://www.googleapis.com/auth/cloud-platform\n"},
+		{PROJECT_ID_PATH, "my-project"},
+	}
+
+	for _, tt := range tests {
+		resp, body := get(t, server.URL+tt.path, metadataHeader())
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", tt.path, resp.StatusCode)
+		}
+		if body != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.expected, body)
+		}
+	}
+
+	resp, _ := get(t, server.URL+SERVICE_ACCOUNTS_PATH+"other@example.com/email", metadataHeader())
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown account, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_TokenSourceError(t *testing.T) {
+	server := newTestServer(t, &countingTokenSource{err: errors.New("refresh failed")})
+
+	resp, _ := get(t, server.URL+SERVICE_ACCOUNTS_PATH+"default/token", metadataHeader())
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the token cannot be obtained, got %d", resp.StatusCode)
+	}
+}
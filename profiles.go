@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/logger"
+	"google-auth-wizard/storage"
+	"google-auth-wizard/utils"
+	"os"
+)
+
+func openTokenStore(cfg *config.Config, dir string) (storage.TokenStore, error) {
+	profilesDir := storage.GetProfilesDir(dir)
+
+	switch cfg.Storage.Backend {
+	case "", config.STORAGE_FILE:
+		return storage.NewFileTokenStore(profilesDir), nil
+	case config.STORAGE_ENCRYPTED:
+		passphrase := os.Getenv("GOOGLE_AUTH_WIZARD_PASSPHRASE")
+		if passphrase == "" {
+			var err error
+			passphrase, err = utils.ReadPassphrase("Token storage passphrase: ")
+			if err != nil {
+				return nil, fmt.Errorf("encrypted token storage: %w (set GOOGLE_AUTH_WIZARD_PASSPHRASE)", err)
+			}
+		}
+		return storage.NewEncryptedTokenStore(profilesDir, passphrase)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %q", cfg.Storage.Backend)
+	}
+}
+
+func storageDir(cfg *config.Config) string {
+	if cfg.Storage.Directory != "" {
+		return cfg.Storage.Directory
+	}
+	return storage.GetDefaultStorageDir()
+}
+
+func openProfileStore(cfg *config.Config) (*storage.ProfileStore, error) {
+	dir := storageDir(cfg)
+
+	tokenStore, err := openTokenStore(cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	profileStore := storage.NewProfileStore(dir, tokenStore)
+
+	migrated, err := profileStore.MigrateLegacyToken()
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		logger.Info("Migrated existing token to the %q profile", storage.DEFAULT_PROFILE)
+	}
+
+	return profileStore, nil
+}
+
+func openProfile(cfg *config.Config, profile string) (*storage.TokenStorage, string, error) {
+	profileStore, err := openProfileStore(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if profile == "" {
+		profile = profileStore.Current()
+	}
+
+	tokenStorage, err := profileStore.TokenStorage(profile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.Debug("Using profile %q (%s storage)", profile, cfg.Storage.Backend)
+	return tokenStorage, profile, nil
+}
+
+func clearProfile(cfg *config.Config, profile string) error {
+	tokenStorage, profile, err := openProfile(cfg, profile)
+	if err != nil {
+		return err
+	}
+
+	if !tokenStorage.Exists() {
+		fmt.Printf("No saved tokens found for profile %q.\n", profile)
+		return nil
+	}
+
+	if err := tokenStorage.Delete(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved tokens cleared successfully for profile %q.\n", profile)
+	return nil
+}
+
+func runProfiles(args []string) error {
+	flagSet := flag.NewFlagSet("profiles", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("  google-auth-wizard profiles list")
+		fmt.Println("  google-auth-wizard profiles use <name>")
+		fmt.Println("  google-auth-wizard profiles delete <name>")
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+	profileStore, err := openProfileStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	subcommand := flagSet.Arg(0)
+	switch subcommand {
+	case "", "list":
+		return listProfiles(profileStore)
+	case "use", "delete":
+		if flagSet.NArg() != 2 {
+			flagSet.Usage()
+			return fmt.Errorf("profiles %s requires a profile name", subcommand)
+		}
+	default:
+		flagSet.Usage()
+		return fmt.Errorf("unknown profiles command %q", subcommand)
+	}
+
+	name := flagSet.Arg(1)
+	if subcommand == "use" {
+		if err := profileStore.SetCurrent(name); err != nil {
+			return err
+		}
+		fmt.Printf("Switched to profile %q.\n", name)
+		return nil
+	}
+
+	if err := profileStore.Delete(name); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted profile %q.\n", name)
+	return nil
+}
+
+func listProfiles(profileStore *storage.ProfileStore) error {
+	names, err := profileStore.List()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No profiles found.")
+		return nil
+	}
+
+	current := profileStore.Current()
+	for _, name := range names {
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+
+		tokenStorage, err := profileStore.TokenStorage(name)
+		if err != nil {
+			return err
+		}
+
+		storedToken, err := tokenStorage.Load()
+		if err != nil {
+			fmt.Printf("%s %s\t(unreadable: %v)\n", marker, name, err)
+			continue
+		}
+
+		fmt.Printf("%s %s\t%s", marker, name, storedToken.GetSummary())
+		if storedToken.ClientID != "" {
+			fmt.Printf(" | Client: %s", storedToken.ClientID)
+		}
+		if storedToken.ProjectID != "" {
+			fmt.Printf(" | Project: %s", storedToken.ProjectID)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
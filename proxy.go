@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/logger"
+	"google-auth-wizard/proxy"
+	"google-auth-wizard/utils"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+)
+
+func runProxy(args []string) error {
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+
+	flagSet := flag.NewFlagSet("proxy", flag.ContinueOnError)
+	filename := flagSet.String("file", "", "Path to the client secret JSON file, needed to refresh expired tokens")
+	flagSet.StringVar(filename, "f", "", "Path to the client secret JSON file (shortcut)")
+	profile := flagSet.String("profile", "", "Token profile to use (defaults to the current profile)")
+	upstream := flagSet.String("upstream", cfg.Proxy.Upstream, "Upstream URL requests are forwarded to (overrides config)")
+	port := flagSet.Int("port", cfg.Proxy.Port, "Loopback port to listen on (overrides config)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	upstreamURL, err := url.Parse(*upstream)
+	if err != nil || (upstreamURL.Scheme != "http" && upstreamURL.Scheme != "https") || upstreamURL.Host == "" {
+		return fmt.Errorf("invalid upstream %q: must be an http or https URL", *upstream)
+	}
+
+	tokenStorage, name, err := openProfile(cfg, *profile)
+	if err != nil {
+		return err
+	}
+
+	var credentials []byte
+	if *filename != "" {
+		credentials = utils.ReadCredentials(*filename)
+	} else {
+		logger.Warn("No client secret file given with -f, the token of profile %q will not be refreshed", name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	tokenSource := newProfileTokenSource(ctx, tokenStorage, name, credentials)
+	if _, err := tokenSource.Token(); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(utils.LOOPBACK_IPV4, strconv.Itoa(*port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", *port, err)
+	}
+
+	srv := &http.Server{
+		Handler:           proxy.NewProxy(upstreamURL, tokenSource, proxy.WithAllowedHosts(listener.Addr().String())),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.Serve(listener)
+	}()
+
+	fmt.Printf("Proxying http://%s to %s with the token of profile %q (Ctrl+C to stop)\n", listener.Addr(), upstreamURL, name)
+
+	select {
+	case err := <-errChan:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"google-auth-wizard/logger"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"golang.org/x/oauth2"
+)
+
+const MAX_REPLAY_BODY_BYTES = 10 << 20
+
+type Refresher interface {
+	Refresh() (*oauth2.Token, error)
+}
+
+type Proxy struct {
+	upstream    *url.URL
+	tokenSource oauth2.TokenSource
+	transport   http.RoundTripper
+	hosts       []string
+	reverse     *httputil.ReverseProxy
+}
+
+type ProxyOption func(*Proxy)
+
+func WithTransport(transport http.RoundTripper) ProxyOption {
+	return func(p *Proxy) {
+		p.transport = transport
+	}
+}
+
+func WithAllowedHosts(hosts ...string) ProxyOption {
+	return func(p *Proxy) {
+		p.hosts = hosts
+	}
+}
+
+func NewProxy(upstream *url.URL, tokenSource oauth2.TokenSource, options ...ProxyOption) *Proxy {
+	proxy := &Proxy{
+		upstream:    upstream,
+		tokenSource: tokenSource,
+		transport:   http.DefaultTransport,
+	}
+
+	for _, option := range options {
+		option(proxy)
+	}
+
+	proxy.reverse = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(proxy.upstream)
+		},
+		Transport: &authTransport{
+			base:        proxy.transport,
+			tokenSource: proxy.tokenSource,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Error("Proxy request %s %s failed: %v", r.Method, r.URL.Path, err)
+			http.Error(w, fmt.Sprintf("proxy error: %v", err), http.StatusBadGateway)
+		},
+	}
+
+	return proxy
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Origin") != "" {
+		http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+		return
+	}
+
+	if !p.isAllowedHost(r.Host) {
+		http.Error(w, fmt.Sprintf("host %q is not allowed", r.Host), http.StatusForbidden)
+		return
+	}
+
+	logger.Debug("Proxying %s %s to %s", r.Method, r.URL.Path, p.upstream)
+	p.reverse.ServeHTTP(w, r)
+}
+
+func (p *Proxy) isAllowedHost(host string) bool {
+	if len(p.hosts) > 0 {
+		for _, allowed := range p.hosts {
+			if host == allowed {
+				return true
+			}
+		}
+		return false
+	}
+
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+type authTransport struct {
+	base        http.RoundTripper
+	tokenSource oauth2.TokenSource
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, replayable, err := bufferBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := t.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	resp, err := t.send(req, token, body)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !replayable {
+		return resp, err
+	}
+
+	refresher, ok := t.tokenSource.(Refresher)
+	if !ok {
+		return resp, nil
+	}
+
+	logger.Info("Upstream rejected the token, refreshing and retrying %s %s", req.Method, req.URL.Path)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	token, err = refresher.Refresh()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return t.send(req, token, body)
+}
+
+func (t *authTransport) send(req *http.Request, token *oauth2.Token, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	token.SetAuthHeader(out)
+
+	return t.base.RoundTrip(out)
+}
+
+func bufferBody(req *http.Request) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	if req.ContentLength < 0 || req.ContentLength > MAX_REPLAY_BODY_BYTES {
+		return nil, false, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read request body: %w", err)
+	}
+	_ = req.Body.Close()
+
+	return body, true, nil
+}
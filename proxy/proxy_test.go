@@ -0,0 +1,236 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+type fakeTokenSource struct {
+	mu        sync.Mutex
+	current   string
+	refreshed string
+	refreshes int
+}
+
+func (f *fakeTokenSource) Token() (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &oauth2.Token{AccessToken: f.current, TokenType: "Bearer"}, nil
+}
+
+func (f *fakeTokenSource) Refresh() (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refreshes++
+	f.current = f.refreshed
+	return &oauth2.Token{AccessToken: f.current, TokenType: "Bearer"}, nil
+}
+
+type fakeUpstream struct {
+	*httptest.Server
+	mu     sync.Mutex
+	valid  string
+	bodies []string
+}
+
+func newFakeUpstream(t *testing.T, valid string) *fakeUpstream {
+	f := &fakeUpstream{valid: valid}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		f.bodies = append(f.bodies, string(body))
+		f.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+f.valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s %s?%s %s", r.Method, r.URL.Path, r.URL.RawQuery, body)
+	}))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func newTestProxy(t *testing.T, upstream string, tokenSource oauth2.TokenSource) *httptest.Server {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		t.Fatalf("Failed to parse upstream URL: %v", err)
+	}
+
+	server := httptest.NewServer(NewProxy(upstreamURL, tokenSource))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func doRequest(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	return sendRequest(t, req)
+}
+
+func sendRequest(t *testing.T, req *http.Request) (int, string) {
+	t.Helper()
+
+	req.Header.Set("Authorization", "Bearer client-supplied")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestProxy_InjectsBearerToken(t *testing.T) {
+	upstream := newFakeUpstream(t, "valid-token")
+	tokenSource := &fakeTokenSource{current: "valid-token"}
+	server := newTestProxy(t, upstream.URL, tokenSource)
+
+	status, body := doRequest(t, http.MethodGet, server.URL+"/drive/v3/files?pageSize=1", "")
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", status, body)
+	}
+	if body != "GET /drive/v3/files?pageSize=1 " {
+		t.Errorf("Unexpected upstream response: %q", body)
+	}
+	if tokenSource.refreshes != 0 {
+		t.Errorf("Expected no refresh, got %d", tokenSource.refreshes)
+	}
+}
+
+func TestProxy_RefreshesOnUnauthorized(t *testing.T) {
+	upstream := newFakeUpstream(t, "new-token")
+	tokenSource := &fakeTokenSource{current: "old-token", refreshed: "new-token"}
+	server := newTestProxy(t, upstream.URL, tokenSource)
+
+	status, body := doRequest(t, http.MethodPost, server.URL+"/upload", `{"name":"file"}`)
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200 after refreshing, got %d: %s", status, body)
+	}
+	if body != `POST /upload? {"name":"file"}` {
+		t.Errorf("Unexpected upstream response: %q", body)
+	}
+	if tokenSource.refreshes != 1 {
+		t.Errorf("Expected one refresh, got %d", tokenSource.refreshes)
+	}
+
+	upstream.mu.Lock()
+	defer upstream.mu.Unlock()
+	if len(upstream.bodies) != 2 || upstream.bodies[1] != `{"name":"file"}` {
+		t.Errorf("Expected the body to be replayed on retry, got %q", upstream.bodies)
+	}
+}
+
+func TestProxy_GivesUpAfterOneRefresh(t *testing.T) {
+	upstream := newFakeUpstream(t, "never-valid")
+	tokenSource := &fakeTokenSource{current: "old-token", refreshed: "still-wrong"}
+	server := newTestProxy(t, upstream.URL, tokenSource)
+
+	status, _ := doRequest(t, http.MethodGet, server.URL+"/", "")
+	if status != http.StatusUnauthorized {
+		t.Errorf("Expected the upstream 401 to be returned, got %d", status)
+	}
+	if tokenSource.refreshes != 1 {
+		t.Errorf("Expected exactly one refresh, got %d", tokenSource.refreshes)
+	}
+}
+
+func TestProxy_StaticTokenSourceDoesNotRetry(t *testing.T) {
+	upstream := newFakeUpstream(t, "valid-token")
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "wrong-token"})
+	server := newTestProxy(t, upstream.URL, tokenSource)
+
+	status, _ := doRequest(t, http.MethodGet, server.URL+"/", "")
+	if status != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", status)
+	}
+
+	upstream.mu.Lock()
+	defer upstream.mu.Unlock()
+	if len(upstream.bodies) != 1 {
+		t.Errorf("Expected a single upstream request, got %d", len(upstream.bodies))
+	}
+}
+
+func TestProxy_RejectsCrossOriginRequests(t *testing.T) {
+	upstream := newFakeUpstream(t, "valid-token")
+	server := newTestProxy(t, upstream.URL, &fakeTokenSource{current: "valid-token"})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/gmail/v1/users/me/messages", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Origin", "https://attacker.example")
+
+	if status, _ := sendRequest(t, req); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-origin request, got %d", status)
+	}
+
+	upstream.mu.Lock()
+	defer upstream.mu.Unlock()
+	if len(upstream.bodies) != 0 {
+		t.Errorf("Expected no upstream request, got %d", len(upstream.bodies))
+	}
+}
+
+func TestProxy_RejectsForeignHost(t *testing.T) {
+	upstream := newFakeUpstream(t, "valid-token")
+	server := newTestProxy(t, upstream.URL, &fakeTokenSource{current: "valid-token"})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/gmail/v1/users/me/messages", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Host = "rebound.attacker.example"
+
+	if status, _ := sendRequest(t, req); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a rebound host name, got %d", status)
+	}
+
+	upstream.mu.Lock()
+	defer upstream.mu.Unlock()
+	if len(upstream.bodies) != 0 {
+		t.Errorf("Expected no upstream request, got %d", len(upstream.bodies))
+	}
+}
+
+func TestProxy_AllowedHosts(t *testing.T) {
+	upstream := newFakeUpstream(t, "valid-token")
+	upstreamURL, _ := url.Parse(upstream.URL)
+	proxy := NewProxy(upstreamURL, &fakeTokenSource{current: "valid-token"}, WithAllowedHosts("127.0.0.1:8990"))
+
+	tests := map[string]int{
+		"127.0.0.1:8990": http.StatusOK,
+		"127.0.0.1:8991": http.StatusForbidden,
+		"localhost:8990": http.StatusForbidden,
+	}
+	for host, expected := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		recorder := httptest.NewRecorder()
+		proxy.ServeHTTP(recorder, req)
+
+		if recorder.Code != expected {
+			t.Errorf("Expected %d for host %s, got %d", expected, host, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"google-auth-wizard/auth"
+	"google-auth-wizard/config"
+	"google-auth-wizard/logger"
+	"os"
+	"os/signal"
+)
+
+func runRevoke(args []string) error {
+	flagSet := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	profile := flagSet.String("profile", "", "Token profile to revoke (defaults to the current profile)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+	tokenStorage, name, err := openProfile(cfg, *profile)
+	if err != nil {
+		return err
+	}
+
+	if !tokenStorage.Exists() {
+		fmt.Printf("No saved tokens found for profile %q.\n", name)
+		return nil
+	}
+
+	unlock, err := tokenStorage.Lock()
+	if err != nil {
+		return fmt.Errorf("failed to lock profile %q: %w", name, err)
+	}
+	defer unlock()
+
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load saved token: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	logger.Debug("Revoking token at %s", cfg.OAuth.RevokeURL)
+
+	var revokeErr *auth.RevokeError
+	err = auth.RevokeToken(ctx, cfg.OAuth.RevokeURL, storedToken.Token)
+	switch {
+	case err == nil:
+		fmt.Println("Token revoked at Google.")
+	case errors.As(err, &revokeErr) && revokeErr.AlreadyInvalid():
+		fmt.Println("Token was already expired or revoked at Google.")
+	default:
+		return fmt.Errorf("failed to revoke token, saved token kept: %w", err)
+	}
+
+	if err := tokenStorage.Delete(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved tokens cleared successfully for profile %q.\n", name)
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/googlescopes"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const (
+	DIFF_FORMAT_TEXT = "text"
+	DIFF_FORMAT_JSON = "json"
+
+	DIFF_EXIT_DIFFERENCES = 1
+	DIFF_EXIT_ERROR       = 2
+)
+
+func runScopes(args []string) error {
+	usage := func() {
+		fmt.Println("Usage:")
+		fmt.Println("  google-auth-wizard scopes diff [-format text|json]                     # Cached catalog vs fresh fetch")
+		fmt.Println("  google-auth-wizard scopes diff [-format text|json] <old.json>          # JSON file vs fresh fetch")
+		fmt.Println("  google-auth-wizard scopes diff [-format text|json] <old.json> <new.json>")
+	}
+
+	if len(args) == 0 {
+		usage()
+		return &exitCodeError{code: DIFF_EXIT_ERROR, err: fmt.Errorf("scopes requires a command")}
+	}
+
+	switch args[0] {
+	case "diff":
+		return runScopesDiff(args[1:], os.Stdout, usage)
+	default:
+		usage()
+		return &exitCodeError{code: DIFF_EXIT_ERROR, err: fmt.Errorf("unknown scopes command %q", args[0])}
+	}
+}
+
+func runScopesDiff(args []string, out io.Writer, usage func()) error {
+	diff, err := scopesDiff(args, out, usage)
+	if err != nil {
+		return &exitCodeError{code: DIFF_EXIT_ERROR, err: err}
+	}
+
+	if !diff.IsEmpty() {
+		return &exitCodeError{code: DIFF_EXIT_DIFFERENCES}
+	}
+	return nil
+}
+
+func scopesDiff(args []string, out io.Writer, usage func()) (*googlescopes.CatalogDiff, error) {
+	flagSet := flag.NewFlagSet("scopes diff", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		usage()
+		fmt.Println("\nOptions:")
+		flagSet.PrintDefaults()
+	}
+	format := flagSet.String("format", DIFF_FORMAT_TEXT, "Output format: text or json")
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *format != DIFF_FORMAT_TEXT && *format != DIFF_FORMAT_JSON {
+		return nil, fmt.Errorf("invalid format %q: must be %s or %s", *format, DIFF_FORMAT_TEXT, DIFF_FORMAT_JSON)
+	}
+
+	if flagSet.NArg() > 2 {
+		flagSet.Usage()
+		return nil, fmt.Errorf("scopes diff takes at most two files")
+	}
+
+	oldServices, newServices, err := diffInputs(flagSet.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	diff := googlescopes.Diff(oldServices, newServices)
+	if err := writeDiff(out, *format, diff); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+func diffInputs(files []string) (*googlescopes.GoogleServices, *googlescopes.GoogleServices, error) {
+	if len(files) == 2 {
+		oldServices, err := readServicesFile(files[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		newServices, err := readServicesFile(files[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return oldServices, newServices, nil
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+
+	var oldServices *googlescopes.GoogleServices
+	if len(files) == 1 {
+		services, err := readServicesFile(files[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		oldServices = services
+	} else {
+		cached, err := newScopeClient(cfg).CachedCatalog()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w; run the wizard once or pass a JSON file to compare against", err)
+		}
+		oldServices = cached.Services
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	catalog, err := newScopeClient(cfg, googlescopes.WithForceRefresh(true), googlescopes.WithReadOnlyCache(true)).FetchCatalog(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch Google scopes: %w", err)
+	}
+	if catalog.FetchErr != nil {
+		return nil, nil, fmt.Errorf("failed to fetch Google scopes: %w", catalog.FetchErr)
+	}
+
+	return oldServices, catalog.Services, nil
+}
+
+func readServicesFile(filename string) (*googlescopes.GoogleServices, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read scope catalog: %w", err)
+	}
+
+	services, err := googlescopes.FromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scope catalog %s: %w", filename, err)
+	}
+	return services, nil
+}
+
+func writeDiff(w io.Writer, format string, diff *googlescopes.CatalogDiff) error {
+	if format == DIFF_FORMAT_JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+
+	if diff.IsEmpty() {
+		_, err := fmt.Fprintln(w, "No differences.")
+		return err
+	}
+
+	if len(diff.AddedServices) > 0 {
+		_, _ = fmt.Fprintf(w, "Added services (%d):\n", len(diff.AddedServices))
+		for _, service := range diff.AddedServices {
+			_, _ = fmt.Fprintf(w, "  + %s\n", service)
+		}
+	}
+
+	if len(diff.RemovedServices) > 0 {
+		_, _ = fmt.Fprintf(w, "Removed services (%d):\n", len(diff.RemovedServices))
+		for _, service := range diff.RemovedServices {
+			_, _ = fmt.Fprintf(w, "  - %s\n", service)
+		}
+	}
+
+	if len(diff.AddedScopes) > 0 {
+		_, _ = fmt.Fprintf(w, "Added scopes (%d):\n", len(diff.AddedScopes))
+		for _, scope := range diff.AddedScopes {
+			_, _ = fmt.Fprintf(w, "  + [%s] %s\n      %s\n", scope.Service, scope.URL, scope.Description)
+		}
+	}
+
+	if len(diff.RemovedScopes) > 0 {
+		_, _ = fmt.Fprintf(w, "Removed scopes (%d):\n", len(diff.RemovedScopes))
+		for _, scope := range diff.RemovedScopes {
+			_, _ = fmt.Fprintf(w, "  - [%s] %s\n      %s\n", scope.Service, scope.URL, scope.Description)
+		}
+	}
+
+	if len(diff.ChangedDescriptions) > 0 {
+		_, _ = fmt.Fprintf(w, "Changed descriptions (%d):\n", len(diff.ChangedDescriptions))
+		for _, change := range diff.ChangedDescriptions {
+			_, _ = fmt.Fprintf(w, "  ~ [%s] %s\n      old: %s\n      new: %s\n", change.Service, change.URL, change.OldDescription, change.NewDescription)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d difference(s)\n", diff.Count())
+	return err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCatalog(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write catalog: %v", err)
+	}
+	return path
+}
+
+func TestRunScopesDiff_ExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	oldCatalog := writeCatalog(t, dir, "old.json", `{"Drive API":[{"url":"https://www.googleapis.com/auth/drive","description":"Drive"}]}`)
+	sameCatalog := writeCatalog(t, dir, "same.json", `{"Drive API":[{"url":"https://www.googleapis.com/auth/drive","description":"Drive"}]}`)
+	newCatalog := writeCatalog(t, dir, "new.json", `{"Drive API":[{"url":"https://www.googleapis.com/auth/drive","description":"Drive"},{"url":"https://www.googleapis.com/auth/drive.file","description":"Files"}]}`)
+	invalidCatalog := writeCatalog(t, dir, "invalid.json", `not json`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{"no differences", []string{oldCatalog, sameCatalog}, 0, "No differences."},
+		{"differences", []string{oldCatalog, newCatalog}, DIFF_EXIT_DIFFERENCES, "drive.file"},
+		{"differences as json", []string{"-format", "json", oldCatalog, newCatalog}, DIFF_EXIT_DIFFERENCES, `"added_scopes"`},
+		{"missing file", []string{oldCatalog, filepath.Join(dir, "missing.json")}, DIFF_EXIT_ERROR, ""},
+		{"invalid catalog", []string{oldCatalog, invalidCatalog}, DIFF_EXIT_ERROR, ""},
+		{"invalid format", []string{"-format", "yaml", oldCatalog, newCatalog}, DIFF_EXIT_ERROR, ""},
+		{"too many files", []string{oldCatalog, sameCatalog, newCatalog}, DIFF_EXIT_ERROR, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runScopesDiff(tt.args, &out, func() {})
+
+			code := 0
+			var exitErr *exitCodeError
+			if errors.As(err, &exitErr) {
+				code = exitErr.code
+			} else if err != nil {
+				t.Fatalf("expected exitCodeError, got %v", err)
+			}
+
+			if code != tt.wantCode {
+				t.Errorf("expected exit code %d, got %d (err: %v)", tt.wantCode, code, err)
+			}
+			if tt.wantCode == DIFF_EXIT_ERROR && exitErr.err == nil {
+				t.Error("expected error exit to carry the underlying error")
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("expected output to contain %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/logger"
+	"google-auth-wizard/metadata"
+	"google-auth-wizard/utils"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+)
+
+const DEFAULT_METADATA_PORT = 8989
+
+func runServeMetadata(args []string) error {
+	flagSet := flag.NewFlagSet("serve-metadata", flag.ContinueOnError)
+	filename := flagSet.String("file", "", "Path to the client secret JSON file, needed to refresh expired tokens")
+	flagSet.StringVar(filename, "f", "", "Path to the client secret JSON file (shortcut)")
+	profile := flagSet.String("profile", "", "Token profile to serve (defaults to the current profile)")
+	port := flagSet.Int("port", DEFAULT_METADATA_PORT, "Loopback port to listen on")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+	tokenStorage, name, err := openProfile(cfg, *profile)
+	if err != nil {
+		return err
+	}
+
+	var credentials []byte
+	if *filename != "" {
+		credentials = utils.ReadCredentials(*filename)
+	} else {
+		logger.Warn("No client secret file given with -f, the token of profile %q will not be refreshed", name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	tokenSource := newProfileTokenSource(ctx, tokenStorage, name, credentials)
+	storedToken, err := tokenSource.StoredToken()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(utils.LOOPBACK_IPV4, strconv.Itoa(*port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", *port, err)
+	}
+
+	srv := &http.Server{
+		Handler: metadata.NewServer(tokenSource,
+			metadata.WithEmail(storedToken.Email),
+			metadata.WithScopes(storedToken.Scopes),
+			metadata.WithProjectID(storedToken.ProjectID),
+		),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.Serve(listener)
+	}()
+
+	host := listener.Addr().String()
+	fmt.Printf("Serving metadata for profile %q on http://%s (Ctrl+C to stop)\n", name, host)
+	fmt.Printf("  export GCE_METADATA_HOST=%s\n", host)
+	fmt.Printf("  export GCE_METADATA_IP=%s\n", host)
+
+	select {
+	case err := <-errChan:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
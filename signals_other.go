@@ -0,0 +1,23 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// Console interrupts already reach every process attached to the console.
+func sharesForegroundProcessGroup() bool {
+	return true
+}
+
+func shouldForward(sig os.Signal, foreground bool) bool {
+	return !foreground || sig != os.Interrupt
+}
+
+func exitCode(state *os.ProcessState) int {
+	if code := state.ExitCode(); code >= 0 {
+		return code
+	}
+	return 1
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"slices"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// Signals the terminal already delivers to its whole foreground process group.
+var terminalSignals = []os.Signal{os.Interrupt, syscall.SIGHUP, syscall.SIGQUIT}
+
+func sharesForegroundProcessGroup() bool {
+	foreground, err := unix.IoctlGetInt(int(os.Stdin.Fd()), unix.TIOCGPGRP)
+	return err == nil && foreground == unix.Getpgrp()
+}
+
+func shouldForward(sig os.Signal, foreground bool) bool {
+	return !foreground || !slices.Contains(terminalSignals, sig)
+}
+
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	if code := state.ExitCode(); code >= 0 {
+		return code
+	}
+	return 1
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunForwardingSignals_ForwardsSIGTERM(t *testing.T) {
+	cmd := helperCommand("wait")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to create stdout pipe: %v", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- runForwardingSignals(cmd)
+	}()
+
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatalf("Expected the helper to start, got %q (%v)", line, err)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("Failed to signal the test process: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		var exitErr *exitCodeError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("Expected an exitCodeError, got %v", err)
+		}
+		if exitErr.code != 128+int(syscall.SIGTERM) {
+			t.Errorf("Expected exit code %d, got %d", 128+int(syscall.SIGTERM), exitErr.code)
+		}
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("Expected SIGTERM to be forwarded to the child")
+	}
+}
+
+func TestShouldForward(t *testing.T) {
+	tests := []struct {
+		sig        os.Signal
+		foreground bool
+		expected   bool
+	}{
+		{os.Interrupt, true, false},
+		{syscall.SIGQUIT, true, false},
+		{syscall.SIGHUP, true, false},
+		{syscall.SIGTERM, true, true},
+		{os.Interrupt, false, true},
+		{syscall.SIGTERM, false, true},
+	}
+
+	for _, tt := range tests {
+		if got := shouldForward(tt.sig, tt.foreground); got != tt.expected {
+			t.Errorf("shouldForward(%v, %v) = %v, expected %v", tt.sig, tt.foreground, got, tt.expected)
+		}
+	}
+}
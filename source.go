@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google-auth-wizard/auth"
+	"google-auth-wizard/logger"
+	"google-auth-wizard/storage"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+type profileTokenSource struct {
+	ctx          context.Context
+	tokenStorage *storage.TokenStorage
+	profile      string
+	credentials  []byte
+
+	mu          sync.Mutex
+	storedToken *storage.StoredToken
+}
+
+func newProfileTokenSource(ctx context.Context, tokenStorage *storage.TokenStorage, profile string, credentials []byte) *profileTokenSource {
+	return &profileTokenSource{
+		ctx:          ctx,
+		tokenStorage: tokenStorage,
+		profile:      profile,
+		credentials:  credentials,
+	}
+}
+
+func (s *profileTokenSource) Token() (*oauth2.Token, error) {
+	storedToken, err := s.StoredToken()
+	if err != nil {
+		return nil, err
+	}
+	return storedToken.Token, nil
+}
+
+func (s *profileTokenSource) StoredToken() (*storage.StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.storedToken != nil && s.storedToken.IsValid() {
+		return s.storedToken, nil
+	}
+
+	storedToken, err := s.load("")
+	if err != nil {
+		return nil, err
+	}
+
+	s.storedToken = storedToken
+	return storedToken, nil
+}
+
+func (s *profileTokenSource) Refresh() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rejected string
+	if s.storedToken != nil {
+		rejected = s.storedToken.Token.AccessToken
+	}
+
+	storedToken, err := s.load(rejected)
+	if err != nil {
+		return nil, err
+	}
+
+	s.storedToken = storedToken
+	return storedToken.Token, nil
+}
+
+func (s *profileTokenSource) load(rejected string) (*storage.StoredToken, error) {
+	unlock, err := s.tokenStorage.Lock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock profile %q: %w", s.profile, err)
+	}
+	defer unlock()
+
+	storedToken, err := s.tokenStorage.Load()
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return nil, fmt.Errorf("no saved token for profile %q; run the wizard with -profile %s first", s.profile, s.profile)
+		}
+		return nil, fmt.Errorf("failed to load profile %q: %w", s.profile, err)
+	}
+
+	if storedToken.IsValid() && (rejected == "" || storedToken.Token.AccessToken != rejected) {
+		return storedToken, nil
+	}
+
+	if !storedToken.CanRefresh() {
+		return nil, fmt.Errorf("token for profile %q is no longer valid and has no refresh token; run the wizard again", s.profile)
+	}
+
+	if s.credentials == nil {
+		return nil, fmt.Errorf("token for profile %q is no longer valid; pass the client secret file with -f to refresh it", s.profile)
+	}
+
+	oauthConfig, err := auth.CreateOAuthConfig(s.credentials, storedToken.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OAuth config: %w", err)
+	}
+
+	legacy := storedToken.IsLegacy()
+	if !legacy && !storedToken.BelongsTo(oauthConfig.ClientID) {
+		return nil, fmt.Errorf("profile %q was not obtained with client %s", s.profile, oauthConfig.ClientID)
+	}
+
+	logger.Info("Refreshing token for profile %q...", s.profile)
+	token, err := auth.RefreshToken(s.ctx, oauthConfig, storedToken.Token)
+	if err != nil {
+		if legacy {
+			return nil, fmt.Errorf("legacy token for profile %q could not be refreshed with client %s and must be re-authorized; run the wizard again: %w", s.profile, oauthConfig.ClientID, err)
+		}
+		if auth.IsInvalidGrant(err) {
+			return nil, fmt.Errorf("refresh token for profile %q was revoked or has expired; run the wizard again to consent", s.profile)
+		}
+		return nil, err
+	}
+
+	if legacy {
+		storedToken.ClientID = oauthConfig.ClientID
+	}
+
+	if err := s.tokenStorage.Save(token, storedToken.Scopes, storedToken.Owner()); err != nil {
+		logger.Error("Failed to save refreshed token: %v", err)
+	}
+
+	storedToken.Token = token
+	storedToken.ExpiresAt = token.Expiry
+	return storedToken, nil
+}
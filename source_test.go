@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"google-auth-wizard/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func (f *fakeTokenServer) credentials(clientID string) []byte {
+	return []byte(fmt.Sprintf(`{"installed": {
+		"client_id": %q,
+		"project_id": "test-project",
+		"auth_uri": "https://accounts.google.com/o/oauth2/auth",
+		"token_uri": %q,
+		"client_secret": "test-client-secret",
+		"redirect_uris": ["http://localhost"]
+	}}`, clientID, f.URL))
+}
+
+func newTestTokenStorage(t *testing.T, token *oauth2.Token, owner storage.Owner) *storage.TokenStorage {
+	t.Helper()
+
+	tokenStorage := storage.NewTokenStorage(storage.NewFileTokenStore(t.TempDir()), "work")
+	if err := tokenStorage.Save(token, []string{"openid"}, owner); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+	return tokenStorage
+}
+
+func TestProfileTokenSource_ValidToken(t *testing.T) {
+	server := newFakeTokenServer(t)
+	token := &oauth2.Token{AccessToken: "valid-access-token", Expiry: time.Now().Add(time.Hour)}
+	tokenStorage := newTestTokenStorage(t, token, storage.Owner{ClientID: testClientID})
+
+	got, err := newProfileTokenSource(context.Background(), tokenStorage, "work", nil).Token()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.AccessToken != "valid-access-token" {
+		t.Errorf("Expected the stored access token, got %q", got.AccessToken)
+	}
+	if refreshes := server.refreshCount(); refreshes != 0 {
+		t.Errorf("Expected no refresh for a valid token, got %d", refreshes)
+	}
+}
+
+func TestProfileTokenSource_RefreshesAndSaves(t *testing.T) {
+	server := newFakeTokenServer(t)
+	owner := storage.Owner{ClientID: testClientID, Email: "user@example.com"}
+	tokenStorage := newTestTokenStorage(t, expiredToken(), owner)
+	source := newProfileTokenSource(context.Background(), tokenStorage, "work", server.credentials(testClientID))
+
+	storedToken, err := source.StoredToken()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if storedToken.Token.AccessToken != "refreshed-access-token" {
+		t.Errorf("Expected the refreshed access token, got %q", storedToken.Token.AccessToken)
+	}
+
+	saved, err := tokenStorage.Load()
+	if err != nil {
+		t.Fatalf("Expected to load the profile, got %v", err)
+	}
+	if saved.Token.AccessToken != "refreshed-access-token" || saved.Token.RefreshToken != testRefreshToken {
+		t.Errorf("Expected the refreshed token to be saved with its refresh token, got %+v", saved.Token)
+	}
+	if saved.Owner() != owner {
+		t.Errorf("Expected the owner to be kept, got %+v", saved.Owner())
+	}
+
+	if _, err := source.Token(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refreshes := server.refreshCount(); refreshes != 1 {
+		t.Errorf("Expected the refreshed token to be cached, got %d refreshes", refreshes)
+	}
+
+	if _, err := source.Refresh(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refreshes := server.refreshCount(); refreshes != 2 {
+		t.Errorf("Expected a rejected token to be refreshed again, got %d refreshes", refreshes)
+	}
+}
+
+func TestProfileTokenSource_ClientMismatch(t *testing.T) {
+	server := newFakeTokenServer(t)
+	tokenStorage := newTestTokenStorage(t, expiredToken(), storage.Owner{ClientID: "other-client-id"})
+
+	_, err := newProfileTokenSource(context.Background(), tokenStorage, "work", server.credentials(testClientID)).Token()
+	if err == nil || !strings.Contains(err.Error(), "was not obtained with client "+testClientID) {
+		t.Errorf("Expected a client mismatch error, got %v", err)
+	}
+	if refreshes := server.refreshCount(); refreshes != 0 {
+		t.Errorf("Expected no refresh with another client, got %d", refreshes)
+	}
+}
+
+func TestProfileTokenSource_ExpiredWithoutCredentials(t *testing.T) {
+	tokenStorage := newTestTokenStorage(t, expiredToken(), storage.Owner{ClientID: testClientID})
+
+	_, err := newProfileTokenSource(context.Background(), tokenStorage, "work", nil).Token()
+	if err == nil || !strings.Contains(err.Error(), "-f") {
+		t.Errorf("Expected an error asking for the client secret file, got %v", err)
+	}
+}
+
+func TestProfileTokenSource_RevokedRefreshToken(t *testing.T) {
+	server := newFakeTokenServer(t)
+	token := expiredToken()
+	token.RefreshToken = "revoked-refresh-token"
+	tokenStorage := newTestTokenStorage(t, token, storage.Owner{ClientID: testClientID})
+
+	_, err := newProfileTokenSource(context.Background(), tokenStorage, "work", server.credentials(testClientID)).Token()
+	if err == nil || !strings.Contains(err.Error(), "revoked or has expired") {
+		t.Errorf("Expected a revoked refresh token error, got %v", err)
+	}
+}
+
+func TestProfileTokenSource_MissingProfile(t *testing.T) {
+	tokenStorage := storage.NewTokenStorage(storage.NewFileTokenStore(t.TempDir()), "work")
+
+	_, err := newProfileTokenSource(context.Background(), tokenStorage, "work", nil).Token()
+	if err == nil || !strings.Contains(err.Error(), "no saved token") {
+		t.Errorf("Expected a missing profile error, got %v", err)
+	}
+}
+
+func TestProfileTokenSource_AdoptsClientForLegacyToken(t *testing.T) {
+	server := newFakeTokenServer(t)
+	tokenStorage := migrateLegacyToken(t, expiredToken(), []string{"openid"})
+
+	if _, err := newProfileTokenSource(context.Background(), tokenStorage, storage.DEFAULT_PROFILE, server.credentials(testClientID)).Token(); err != nil {
+		t.Fatalf("Expected the legacy token to be refreshed, got %v", err)
+	}
+
+	saved, err := tokenStorage.Load()
+	if err != nil {
+		t.Fatalf("Expected to load the profile, got %v", err)
+	}
+	if !saved.BelongsTo(testClientID) {
+		t.Errorf("Expected the legacy token to adopt client %s, got %q", testClientID, saved.ClientID)
+	}
+}
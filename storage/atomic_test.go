@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "second" {
+		t.Errorf("Expected file to be replaced, got %q (err %v)", data, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected file to exist, got %v", err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFileAtomic_MissingDirectory(t *testing.T) {
+	if err := WriteFileAtomic(filepath.Join(t.TempDir(), "missing", "token.json"), []byte("data"), 0600); err == nil {
+		t.Error("Expected error when the directory does not exist, got nil")
+	}
+}
+
+func TestTokenStorage_ConcurrentSavesAndLoads(t *testing.T) {
+	tokenStorage := NewTokenStorage(NewFileTokenStore(t.TempDir()), "work")
+	if err := tokenStorage.Save(&oauth2.Token{AccessToken: "initial"}, []string{"openid"}, Owner{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			token := &oauth2.Token{AccessToken: fmt.Sprintf("access-token-%d", i), Expiry: time.Now().Add(time.Hour)}
+			if err := tokenStorage.Save(token, []string{"openid"}, Owner{ClientID: "client-id"}); err != nil {
+				errs <- err
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			if _, err := tokenStorage.Load(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Expected readers never to observe a partial write, got %v", err)
+	}
+}
+
+func TestTokenStorage_LockSerializesUpdates(t *testing.T) {
+	stores := map[string]TokenStore{
+		"File":      NewFileTokenStore(t.TempDir()),
+		"Encrypted": newTestEncryptedStore(t, t.TempDir(), "passphrase"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			tokenStorage := NewTokenStorage(store, "work")
+			if err := tokenStorage.Save(&oauth2.Token{AccessToken: "initial"}, nil, Owner{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			const writers = 10
+			var wg sync.WaitGroup
+			errs := make(chan error, writers)
+
+			for i := 0; i < writers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					unlock, err := tokenStorage.Lock()
+					if err != nil {
+						errs <- err
+						return
+					}
+					defer unlock()
+
+					storedToken, err := tokenStorage.Load()
+					if err != nil {
+						errs <- err
+						return
+					}
+
+					scopes := append(storedToken.Scopes, fmt.Sprintf("scope-%d", i))
+					if err := tokenStorage.Save(storedToken.Token, scopes, Owner{}); err != nil {
+						errs <- err
+					}
+				}(i)
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			storedToken, err := tokenStorage.Load()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(storedToken.Scopes) != writers {
+				t.Errorf("Expected %d updates to survive, got %d: %v", writers, len(storedToken.Scopes), storedToken.Scopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	ENCRYPTED_STORE_EXT = ".enc"
+	ENCRYPTION_KDF      = "scrypt"
+	ENCRYPTION_CIPHER   = "aes-256-gcm"
+
+	SCRYPT_N       = 1 << 15
+	SCRYPT_MAX_N   = 1 << 20
+	SCRYPT_R       = 8
+	SCRYPT_P       = 1
+	SCRYPT_KEY_LEN = 32
+	SALT_BYTES     = 16
+)
+
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted token file")
+
+type EncryptedTokenStore struct {
+	files      profileFiles
+	passphrase []byte
+	scryptN    int
+}
+
+type encryptedEnvelope struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func NewEncryptedTokenStore(dir string, passphrase string) (*EncryptedTokenStore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encrypted token storage requires a passphrase")
+	}
+
+	return &EncryptedTokenStore{
+		files:      profileFiles{dir: dir, ext: ENCRYPTED_STORE_EXT},
+		passphrase: []byte(passphrase),
+		scryptN:    SCRYPT_N,
+	}, nil
+}
+
+func (es *EncryptedTokenStore) Save(profile string, storedToken *StoredToken) error {
+	plaintext, err := encodeStoredToken(storedToken)
+	if err != nil {
+		return err
+	}
+
+	envelope := encryptedEnvelope{
+		KDF:    ENCRYPTION_KDF,
+		N:      es.scryptN,
+		R:      SCRYPT_R,
+		P:      SCRYPT_P,
+		Salt:   make([]byte, SALT_BYTES),
+		Cipher: ENCRYPTION_CIPHER,
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := es.aead(envelope)
+	if err != nil {
+		return err
+	}
+
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, plaintext, []byte(profile))
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal encrypted token: %w", err)
+	}
+
+	return es.files.write(profile, data)
+}
+
+func (es *EncryptedTokenStore) Load(profile string) (*StoredToken, error) {
+	data, err := es.files.read(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted token file: %w", err)
+	}
+
+	if envelope.KDF != ENCRYPTION_KDF || envelope.Cipher != ENCRYPTION_CIPHER || envelope.N > SCRYPT_MAX_N {
+		return nil, fmt.Errorf("unsupported token encryption: %s/%s", envelope.KDF, envelope.Cipher)
+	}
+
+	if envelope.R != SCRYPT_R || envelope.P != SCRYPT_P {
+		return nil, fmt.Errorf("unsupported scrypt parameters: r=%d p=%d", envelope.R, envelope.P)
+	}
+
+	aead, err := es.aead(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(profile))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return decodeStoredToken(plaintext)
+}
+
+func (es *EncryptedTokenStore) Delete(profile string) error {
+	return es.files.remove(profile)
+}
+
+func (es *EncryptedTokenStore) List() ([]string, error) {
+	return es.files.list()
+}
+
+func (es *EncryptedTokenStore) aead(envelope encryptedEnvelope) (cipher.AEAD, error) {
+	key, err := scrypt.Key(es.passphrase, envelope.Salt, envelope.N, envelope.R, envelope.P, SCRYPT_KEY_LEN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (es *EncryptedTokenStore) lock(profile string) (*fileLock, error) {
+	return es.files.lock(profile)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestNewEncryptedTokenStore_EmptyPassphrase(t *testing.T) {
+	if _, err := NewEncryptedTokenStore(t.TempDir(), ""); err == nil {
+		t.Error("Expected error for an empty passphrase, got nil")
+	}
+}
+
+func TestEncryptedTokenStore_CiphertextOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestEncryptedStore(t, dir, "passphrase")
+
+	storedToken := &StoredToken{Token: &oauth2.Token{AccessToken: "secret-access-token", RefreshToken: "secret-refresh-token"}}
+	if err := store.Save("work", storedToken); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "work"+ENCRYPTED_STORE_EXT))
+	if err != nil {
+		t.Fatalf("Expected encrypted file to exist, got %v", err)
+	}
+
+	if strings.Contains(string(data), "secret-") {
+		t.Error("Expected token values not to appear in plaintext on disk")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "work"+ENCRYPTED_STORE_EXT))
+	if err != nil {
+		t.Fatalf("Expected encrypted file to exist, got %v", err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestEncryptedTokenStore_WrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+
+	storedToken := &StoredToken{Token: &oauth2.Token{RefreshToken: "refresh-token"}}
+	if err := newTestEncryptedStore(t, dir, "right").Save("work", storedToken); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := newTestEncryptedStore(t, dir, "wrong").Load("work"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+}
+
+func TestEncryptedTokenStore_BoundToProfile(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestEncryptedStore(t, dir, "passphrase")
+
+	if err := store.Save("work", &StoredToken{Token: &oauth2.Token{RefreshToken: "work-token"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "work"+ENCRYPTED_STORE_EXT))
+	if err != nil {
+		t.Fatalf("Expected encrypted file to exist, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "personal"+ENCRYPTED_STORE_EXT), data, 0600); err != nil {
+		t.Fatalf("Failed to copy encrypted file: %v", err)
+	}
+
+	if _, err := store.Load("personal"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected a token copied to another profile to be rejected, got %v", err)
+	}
+}
+
+func TestEncryptedTokenStore_RejectsUnsupportedScryptParameters(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value int
+	}{
+		{"oversized N", "n", SCRYPT_MAX_N * 2},
+		{"oversized r", "r", 1 << 20},
+		{"oversized p", "p", 1 << 20},
+		{"zero r", "r", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := newTestEncryptedStore(t, dir, "passphrase")
+			if err := store.Save("work", &StoredToken{Token: &oauth2.Token{RefreshToken: "work-token"}}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			path := filepath.Join(dir, "work"+ENCRYPTED_STORE_EXT)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Expected encrypted file to exist, got %v", err)
+			}
+
+			var envelope map[string]any
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				t.Fatalf("Failed to parse encrypted file: %v", err)
+			}
+			envelope[tt.field] = tt.value
+
+			data, err = json.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("Failed to marshal encrypted file: %v", err)
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatalf("Failed to rewrite encrypted file: %v", err)
+			}
+
+			_, err = store.Load("work")
+			if err == nil || errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("Expected unsupported parameters to be rejected before key derivation, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+const FILE_STORE_EXT = ".json"
+
+type FileTokenStore struct {
+	files profileFiles
+}
+
+func NewFileTokenStore(dir string) *FileTokenStore {
+	return &FileTokenStore{
+		files: profileFiles{dir: dir, ext: FILE_STORE_EXT},
+	}
+}
+
+func (fs *FileTokenStore) Save(profile string, storedToken *StoredToken) error {
+	data, err := encodeStoredToken(storedToken)
+	if err != nil {
+		return err
+	}
+
+	return fs.files.write(profile, data)
+}
+
+func (fs *FileTokenStore) Load(profile string) (*StoredToken, error) {
+	data, err := fs.files.read(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeStoredToken(data)
+}
+
+func (fs *FileTokenStore) Delete(profile string) error {
+	return fs.files.remove(profile)
+}
+
+func (fs *FileTokenStore) List() ([]string, error) {
+	return fs.files.list()
+}
+
+func (fs *FileTokenStore) lock(profile string) (*fileLock, error) {
+	return fs.files.lock(profile)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const LOCK_FILE_EXT = ".lock"
+
+type fileLock struct {
+	file *os.File
+}
+
+func lockFile(path string) (*fileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockExclusive(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &fileLock{file: file}, nil
+}
+
+func (l *fileLock) Unlock() error {
+	unlockErr := unlock(l.file)
+	closeErr := l.file.Close()
+	if unlockErr != nil {
+		return fmt.Errorf("failed to unlock %s: %w", l.file.Name(), unlockErr)
+	}
+	return closeErr
+}
@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package storage
+
+import "os"
+
+func lockExclusive(file *os.File) error {
+	return nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
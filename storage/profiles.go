@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	DEFAULT_PROFILE      = "default"
+	PROFILES_DIR         = "profiles"
+	CURRENT_PROFILE_FILE = "current_profile"
+	LEGACY_TOKEN_FILE    = "token.json"
+)
+
+type ProfileStore struct {
+	dir   string
+	store TokenStore
+}
+
+func NewProfileStore(dir string, store TokenStore) *ProfileStore {
+	return &ProfileStore{
+		dir:   dir,
+		store: store,
+	}
+}
+
+func GetDefaultStorageDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".google-auth-wizard"
+	}
+	return filepath.Join(homeDir, ".google-auth-wizard")
+}
+
+func GetProfilesDir(dir string) string {
+	return filepath.Join(dir, PROFILES_DIR)
+}
+
+func (ps *ProfileStore) TokenStorage(name string) (*TokenStorage, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	return NewTokenStorage(ps.store, name), nil
+}
+
+func (ps *ProfileStore) Exists(name string) bool {
+	names, err := ps.List()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(names, name)
+}
+
+func (ps *ProfileStore) List() ([]string, error) {
+	return ps.store.List()
+}
+
+func (ps *ProfileStore) Current() string {
+	data, err := os.ReadFile(filepath.Join(ps.dir, CURRENT_PROFILE_FILE))
+	if err != nil {
+		return DEFAULT_PROFILE
+	}
+
+	name := strings.TrimSpace(string(data))
+	if ValidateProfileName(name) != nil {
+		return DEFAULT_PROFILE
+	}
+	return name
+}
+
+func (ps *ProfileStore) SetCurrent(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	if !ps.Exists(name) {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	if err := os.MkdirAll(ps.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", ps.dir, err)
+	}
+
+	if err := WriteFileAtomic(filepath.Join(ps.dir, CURRENT_PROFILE_FILE), []byte(name+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write current profile: %w", err)
+	}
+
+	return nil
+}
+
+func (ps *ProfileStore) Delete(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	if err := ps.store.Delete(name); err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return fmt.Errorf("profile %q not found", name)
+		}
+		return err
+	}
+
+	if ps.Current() == name {
+		if err := os.Remove(filepath.Join(ps.dir, CURRENT_PROFILE_FILE)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to reset current profile: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (ps *ProfileStore) MigrateLegacyToken() (bool, error) {
+	legacyPath := filepath.Join(ps.dir, LEGACY_TOKEN_FILE)
+	if _, err := os.Stat(legacyPath); err != nil {
+		return false, nil
+	}
+
+	tokenStorage := NewTokenStorage(ps.store, DEFAULT_PROFILE)
+	unlock, err := tokenStorage.Lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(legacyPath)
+	if err != nil || tokenStorage.Exists() {
+		return false, nil
+	}
+
+	storedToken, err := decodeStoredToken(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to read legacy token file: %w", err)
+	}
+
+	if err := ps.store.Save(DEFAULT_PROFILE, storedToken); err != nil {
+		return false, fmt.Errorf("failed to migrate legacy token: %w", err)
+	}
+
+	if err := os.Remove(legacyPath); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove legacy token file: %w", err)
+	}
+
+	return true, nil
+}
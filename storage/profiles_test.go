@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func newFileProfileStore(dir string) *ProfileStore {
+	return NewProfileStore(dir, NewFileTokenStore(GetProfilesDir(dir)))
+}
+
+func saveProfile(t *testing.T, ps *ProfileStore, name string) {
+	t.Helper()
+
+	tokenStorage, err := ps.TokenStorage(name)
+	if err != nil {
+		t.Fatalf("Expected valid profile %q, got %v", name, err)
+	}
+
+	token := &oauth2.Token{AccessToken: name + "-access-token", Expiry: time.Now().Add(time.Hour)}
+	if err := tokenStorage.Save(token, []string{"openid"}, Owner{ClientID: name + "-client-id", Email: name + "@example.com"}); err != nil {
+		t.Fatalf("Failed to save profile %q: %v", name, err)
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"default", "work", "personal.gmail", "client_2-prod"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "../escape", "a/b", ".hidden", "with space"} {
+		if err := ValidateProfileName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestProfileStore_SeparateTokens(t *testing.T) {
+	ps := newFileProfileStore(t.TempDir())
+	saveProfile(t, ps, "work")
+	saveProfile(t, ps, "personal")
+
+	for _, name := range []string{"work", "personal"} {
+		tokenStorage, _ := ps.TokenStorage(name)
+		storedToken, err := tokenStorage.Load()
+		if err != nil {
+			t.Fatalf("Expected to load profile %q, got %v", name, err)
+		}
+
+		if storedToken.Token.AccessToken != name+"-access-token" {
+			t.Errorf("Expected profile %q to keep its own token, got %s", name, storedToken.Token.AccessToken)
+		}
+
+		if !storedToken.BelongsTo(name + "-client-id") {
+			t.Errorf("Expected profile %q to keep its client ID, got %s", name, storedToken.ClientID)
+		}
+
+		if storedToken.Email != name+"@example.com" {
+			t.Errorf("Expected profile %q to keep its account email, got %s", name, storedToken.Email)
+		}
+	}
+
+	names, err := ps.List()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(names, []string{"personal", "work"}) {
+		t.Errorf("Expected sorted profile names, got %v", names)
+	}
+}
+
+func TestProfileStore_CurrentProfile(t *testing.T) {
+	ps := newFileProfileStore(t.TempDir())
+
+	if current := ps.Current(); current != DEFAULT_PROFILE {
+		t.Errorf("Expected default profile, got %s", current)
+	}
+
+	if err := ps.SetCurrent("work"); err == nil {
+		t.Error("Expected error when switching to a missing profile, got nil")
+	}
+
+	saveProfile(t, ps, "work")
+	if err := ps.SetCurrent("work"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if current := ps.Current(); current != "work" {
+		t.Errorf("Expected current profile 'work', got %s", current)
+	}
+
+	if err := ps.Delete("work"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ps.Exists("work") {
+		t.Error("Expected profile to be deleted")
+	}
+
+	if current := ps.Current(); current != DEFAULT_PROFILE {
+		t.Errorf("Expected current profile to fall back to default after deletion, got %s", current)
+	}
+
+	if err := ps.Delete("work"); err == nil {
+		t.Error("Expected error when deleting a missing profile, got nil")
+	}
+}
+
+func TestProfileStore_MigrateLegacyToken(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"token":{"access_token":"legacy-access-token"},"scopes":["openid"]}`
+	if err := os.WriteFile(filepath.Join(dir, LEGACY_TOKEN_FILE), []byte(legacy), 0600); err != nil {
+		t.Fatalf("Failed to write legacy token: %v", err)
+	}
+
+	ps := newFileProfileStore(dir)
+
+	migrated, err := ps.MigrateLegacyToken()
+	if err != nil || !migrated {
+		t.Fatalf("Expected legacy token to be migrated, got migrated=%v err=%v", migrated, err)
+	}
+
+	tokenStorage, _ := ps.TokenStorage(DEFAULT_PROFILE)
+	storedToken, err := tokenStorage.Load()
+	if err != nil {
+		t.Fatalf("Expected to load migrated profile, got %v", err)
+	}
+
+	if storedToken.Token.AccessToken != "legacy-access-token" {
+		t.Errorf("Expected migrated access token, got %s", storedToken.Token.AccessToken)
+	}
+
+	if storedToken.BelongsTo("") {
+		t.Error("Expected a legacy token without client ID not to match any client")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, LEGACY_TOKEN_FILE)); !os.IsNotExist(err) {
+		t.Error("Expected legacy token file to be moved")
+	}
+
+	if migrated, _ := ps.MigrateLegacyToken(); migrated {
+		t.Error("Expected migration to run only once")
+	}
+}
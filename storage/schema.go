@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const TOKEN_SCHEMA_VERSION = 1
+
+type UnsupportedVersionError struct {
+	Version   int
+	Supported int
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("token file uses schema version %d but this version of google-auth-wizard only supports up to %d; please upgrade google-auth-wizard",
+		e.Version, e.Supported)
+}
+
+type tokenMigration func(raw map[string]json.RawMessage) error
+
+var tokenMigrations = []tokenMigration{
+	migrateTokenV0ToV1,
+}
+
+func decodeStoredToken(data []byte) (*StoredToken, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+
+	version := 0
+	if value, ok := raw["version"]; ok {
+		if err := json.Unmarshal(value, &version); err != nil {
+			return nil, fmt.Errorf("failed to parse token schema version: %w", err)
+		}
+	}
+
+	if version < 0 {
+		return nil, fmt.Errorf("invalid token schema version %d", version)
+	}
+
+	if version > TOKEN_SCHEMA_VERSION {
+		return nil, &UnsupportedVersionError{Version: version, Supported: TOKEN_SCHEMA_VERSION}
+	}
+
+	for ; version < TOKEN_SCHEMA_VERSION; version++ {
+		if err := tokenMigrations[version](raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate token from schema version %d: %w", version, err)
+		}
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal migrated token: %w", err)
+	}
+
+	var storedToken StoredToken
+	if err := json.Unmarshal(migrated, &storedToken); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	storedToken.Version = TOKEN_SCHEMA_VERSION
+
+	return &storedToken, nil
+}
+
+func encodeStoredToken(storedToken *StoredToken) ([]byte, error) {
+	versioned := *storedToken
+	versioned.Version = TOKEN_SCHEMA_VERSION
+
+	data, err := json.MarshalIndent(versioned, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token: %w", err)
+	}
+	return data, nil
+}
+
+func migrateTokenV0ToV1(raw map[string]json.RawMessage) error {
+	if scopes, ok := raw["scopes"]; !ok || string(scopes) == "null" {
+		raw["scopes"] = json.RawMessage("[]")
+	}
+
+	if expiresAt, ok := raw["expires_at"]; !ok || string(expiresAt) == "null" {
+		var token struct {
+			Expiry json.RawMessage `json:"expiry"`
+		}
+		if value, ok := raw["token"]; ok {
+			if err := json.Unmarshal(value, &token); err != nil {
+				return fmt.Errorf("failed to parse token: %w", err)
+			}
+		}
+		if token.Expiry != nil {
+			raw["expires_at"] = token.Expiry
+		}
+	}
+
+	raw["version"] = json.RawMessage("1")
+	return nil
+}
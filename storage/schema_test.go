@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestDecodeStoredToken_LegacyV0(t *testing.T) {
+	legacy := `{
+		"token": {"access_token": "legacy-access-token", "refresh_token": "legacy-refresh-token", "expiry": "2030-01-02T03:04:05Z"},
+		"scopes": null,
+		"saved_at": "2025-01-01T00:00:00Z"
+	}`
+
+	storedToken, err := decodeStoredToken([]byte(legacy))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if storedToken.Version != TOKEN_SCHEMA_VERSION {
+		t.Errorf("Expected version %d after migration, got %d", TOKEN_SCHEMA_VERSION, storedToken.Version)
+	}
+
+	if storedToken.Token.RefreshToken != "legacy-refresh-token" {
+		t.Errorf("Expected refresh token to survive migration, got %s", storedToken.Token.RefreshToken)
+	}
+
+	if storedToken.Scopes == nil {
+		t.Error("Expected missing scopes to become an empty list")
+	}
+
+	expected := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !storedToken.ExpiresAt.Equal(expected) {
+		t.Errorf("Expected expires_at to be filled from the token expiry, got %v", storedToken.ExpiresAt)
+	}
+}
+
+func TestEncodeStoredToken_CurrentVersion(t *testing.T) {
+	data, err := encodeStoredToken(&StoredToken{Token: &oauth2.Token{AccessToken: "access-token"}, Scopes: []string{"openid"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	if string(raw["version"]) != "1" {
+		t.Errorf("Expected version 1 to be written, got %s", raw["version"])
+	}
+
+	storedToken, err := decodeStoredToken(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if storedToken.Token.AccessToken != "access-token" || len(storedToken.Scopes) != 1 {
+		t.Errorf("Expected token to round-trip, got %+v", storedToken)
+	}
+}
+
+func TestDecodeStoredToken_NewerVersion(t *testing.T) {
+	_, err := decodeStoredToken([]byte(`{"version": 99, "token": {"access_token": "future"}}`))
+
+	var versionErr *UnsupportedVersionError
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("Expected UnsupportedVersionError, got %v", err)
+	}
+
+	if versionErr.Version != 99 || versionErr.Supported != TOKEN_SCHEMA_VERSION {
+		t.Errorf("Expected version 99 and supported %d, got %+v", TOKEN_SCHEMA_VERSION, versionErr)
+	}
+
+	if !strings.Contains(err.Error(), "upgrade") {
+		t.Errorf("Expected error to suggest upgrading, got %v", err)
+	}
+}
+
+func TestDecodeStoredToken_Invalid(t *testing.T) {
+	for _, data := range []string{`invalid json`, `{"version": "one"}`, `{"version": -1, "token": {}}`} {
+		if _, err := decodeStoredToken([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q, got nil", data)
+		}
+	}
+}
+
+func TestFileTokenStore_NewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileTokenStore(dir)
+
+	if err := store.files.write("work", []byte(`{"version": 2, "token": {}}`)); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	var versionErr *UnsupportedVersionError
+	if _, err := store.Load("work"); !errors.As(err, &versionErr) {
+		t.Errorf("Expected UnsupportedVersionError from the file store, got %v", err)
+	}
+}
+
+func TestTokenStorage_SaveKeepsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileTokenStore(dir)
+
+	future := []byte(`{"version": 2, "token": {}}`)
+	if err := store.files.write("work", future); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	tokenStorage := NewTokenStorage(store, "work")
+
+	var versionErr *UnsupportedVersionError
+	if err := tokenStorage.CheckWritable(); !errors.As(err, &versionErr) {
+		t.Errorf("Expected UnsupportedVersionError from CheckWritable, got %v", err)
+	}
+
+	err := tokenStorage.Save(&oauth2.Token{AccessToken: "new-token"}, nil, Owner{})
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("Expected UnsupportedVersionError from Save, got %v", err)
+	}
+
+	data, err := store.files.read("work")
+	if err != nil {
+		t.Fatalf("Expected token file to remain, got %v", err)
+	}
+	if string(data) != string(future) {
+		t.Errorf("Expected the newer token file to be left untouched, got %s", data)
+	}
+
+	if err := NewTokenStorage(store, "personal").CheckWritable(); err != nil {
+		t.Errorf("Expected a missing profile to be writable, got %v", err)
+	}
+}
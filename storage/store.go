@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type TokenStore interface {
+	Save(profile string, storedToken *StoredToken) error
+	Load(profile string) (*StoredToken, error)
+	Delete(profile string) error
+	List() ([]string, error)
+}
+
+type profileLocker interface {
+	lock(profile string) (*fileLock, error)
+}
+
+var ErrTokenNotFound = errors.New("token not found")
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+type profileFiles struct {
+	dir string
+	ext string
+}
+
+func (pf profileFiles) path(profile string) (string, error) {
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+	return filepath.Join(pf.dir, profile+pf.ext), nil
+}
+
+func (pf profileFiles) read(profile string) ([]byte, error) {
+	path, err := pf.path(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: profile %q", ErrTokenNotFound, profile)
+		}
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	return data, nil
+}
+
+func (pf profileFiles) write(profile string, data []byte) error {
+	path, err := pf.path(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(pf.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", pf.dir, err)
+	}
+
+	if err := WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+func (pf profileFiles) lock(profile string) (*fileLock, error) {
+	if err := ValidateProfileName(profile); err != nil {
+		return nil, err
+	}
+	return lockFile(filepath.Join(pf.dir, profile+LOCK_FILE_EXT))
+}
+
+func (pf profileFiles) remove(profile string) error {
+	path, err := pf.path(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: profile %q", ErrTokenNotFound, profile)
+		}
+		return fmt.Errorf("failed to delete token file: %w", err)
+	}
+	return nil
+}
+
+func (pf profileFiles) list() ([]string, error) {
+	entries, err := os.ReadDir(pf.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), pf.ext)
+		if !ok || entry.IsDir() || ValidateProfileName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func newTestEncryptedStore(t *testing.T, dir, passphrase string) *EncryptedTokenStore {
+	t.Helper()
+
+	store, err := NewEncryptedTokenStore(dir, passphrase)
+	if err != nil {
+		t.Fatalf("Failed to create encrypted store: %v", err)
+	}
+	store.scryptN = 1 << 10
+	return store
+}
+
+func TestTokenStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) TokenStore{
+		"File": func(t *testing.T) TokenStore {
+			return NewFileTokenStore(t.TempDir())
+		},
+		"Encrypted": func(t *testing.T) TokenStore {
+			return newTestEncryptedStore(t, t.TempDir(), "correct horse battery staple")
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			if _, err := store.Load("work"); !errors.Is(err, ErrTokenNotFound) {
+				t.Errorf("Expected ErrTokenNotFound for a missing profile, got %v", err)
+			}
+
+			expiry := time.Now().Add(time.Hour).Round(time.Second)
+			saved := &StoredToken{
+				Token:    &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token", Expiry: expiry},
+				Scopes:   []string{"openid", "email"},
+				ClientID: "client-id",
+				Email:    "user@example.com",
+			}
+
+			for _, profile := range []string{"work", "personal"} {
+				if err := store.Save(profile, saved); err != nil {
+					t.Fatalf("Expected no error saving %q, got %v", profile, err)
+				}
+			}
+
+			loaded, err := store.Load("work")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if loaded.Token.RefreshToken != "refresh-token" || !loaded.Token.Expiry.Equal(expiry) {
+				t.Errorf("Expected token to round-trip, got %+v", loaded.Token)
+			}
+
+			if !reflect.DeepEqual(loaded.Scopes, saved.Scopes) || loaded.Email != saved.Email || !loaded.BelongsTo("client-id") {
+				t.Errorf("Expected metadata to round-trip, got %+v", loaded)
+			}
+
+			names, err := store.List()
+			if err != nil || !reflect.DeepEqual(names, []string{"personal", "work"}) {
+				t.Errorf("Expected [personal work], got %v (err %v)", names, err)
+			}
+
+			if err := store.Delete("work"); err != nil {
+				t.Fatalf("Expected no error deleting, got %v", err)
+			}
+
+			if err := store.Delete("work"); !errors.Is(err, ErrTokenNotFound) {
+				t.Errorf("Expected ErrTokenNotFound deleting twice, got %v", err)
+			}
+
+			if err := store.Save("../escape", saved); err == nil {
+				t.Error("Expected invalid profile names to be rejected")
+			}
+		})
+	}
+}
@@ -1,96 +1,109 @@
 package storage
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"slices"
 	"time"
 
 	"golang.org/x/oauth2"
 )
 
 type TokenStorage struct {
-	filepath string
+	store   TokenStore
+	profile string
+}
+
+type Owner struct {
+	ClientID  string
+	ProjectID string
+	Email     string
 }
 
 type StoredToken struct {
+	Version   int           `json:"version"`
 	Token     *oauth2.Token `json:"token"`
 	Scopes    []string      `json:"scopes"`
+	ClientID  string        `json:"client_id,omitempty"`
+	ProjectID string        `json:"project_id,omitempty"`
+	Email     string        `json:"email,omitempty"`
 	SavedAt   time.Time     `json:"saved_at"`
 	ExpiresAt time.Time     `json:"expires_at"`
 }
 
-func NewTokenStorage(filepath string) *TokenStorage {
+func NewTokenStorage(store TokenStore, profile string) *TokenStorage {
 	return &TokenStorage{
-		filepath: filepath,
+		store:   store,
+		profile: profile,
 	}
 }
 
-func GetDefaultTokenPath() string {
-	homeDir, err := os.UserHomeDir()
+func (ts *TokenStorage) Profile() string {
+	return ts.profile
+}
+
+func (ts *TokenStorage) Lock() (func(), error) {
+	locker, ok := ts.store.(profileLocker)
+	if !ok {
+		return func() {}, nil
+	}
+
+	lock, err := locker.lock(ts.profile)
 	if err != nil {
-		return ".google-auth-wizard-token.json"
+		return nil, err
 	}
-	return filepath.Join(homeDir, ".google-auth-wizard", "token.json")
+
+	return func() {
+		_ = lock.Unlock()
+	}, nil
 }
 
-func (ts *TokenStorage) Save(token *oauth2.Token, scopes []string) error {
-	dir := filepath.Dir(ts.filepath)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+func (ts *TokenStorage) Save(token *oauth2.Token, scopes []string, owner Owner) error {
+	if err := ts.CheckWritable(); err != nil {
+		return err
 	}
 
-	storedToken := StoredToken{
+	storedToken := &StoredToken{
 		Token:     token,
 		Scopes:    scopes,
+		ClientID:  owner.ClientID,
+		ProjectID: owner.ProjectID,
+		Email:     owner.Email,
 		SavedAt:   time.Now(),
 		ExpiresAt: token.Expiry,
 	}
 
-	data, err := json.MarshalIndent(storedToken, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal token: %w", err)
-	}
+	return ts.store.Save(ts.profile, storedToken)
+}
 
-	if err := os.WriteFile(ts.filepath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write token file: %w", err)
+func (ts *TokenStorage) CheckWritable() error {
+	if !ts.Exists() {
+		return nil
 	}
 
+	var versionErr *UnsupportedVersionError
+	if _, err := ts.Load(); errors.As(err, &versionErr) {
+		return err
+	}
 	return nil
 }
 
 func (ts *TokenStorage) Load() (*StoredToken, error) {
-	data, err := os.ReadFile(ts.filepath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("token file not found: %s", ts.filepath)
-		}
-		return nil, fmt.Errorf("failed to read token file: %w", err)
-	}
-
-	var storedToken StoredToken
-	if err := json.Unmarshal(data, &storedToken); err != nil {
-		return nil, fmt.Errorf("failed to parse token file: %w", err)
-	}
-
-	return &storedToken, nil
+	return ts.store.Load(ts.profile)
 }
 
 func (ts *TokenStorage) Exists() bool {
-	_, err := os.Stat(ts.filepath)
-	return !os.IsNotExist(err)
+	names, err := ts.store.List()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(names, ts.profile)
 }
 
 func (ts *TokenStorage) Delete() error {
-	if !ts.Exists() {
-		return nil
+	if err := ts.store.Delete(ts.profile); err != nil && !errors.Is(err, ErrTokenNotFound) {
+		return err
 	}
-
-	if err := os.Remove(ts.filepath); err != nil {
-		return fmt.Errorf("failed to delete token file: %w", err)
-	}
-
 	return nil
 }
 
@@ -106,6 +119,26 @@ func (st *StoredToken) IsValid() bool {
 	return true
 }
 
+func (st *StoredToken) CanRefresh() bool {
+	return st.Token != nil && st.Token.RefreshToken != ""
+}
+
+func (st *StoredToken) Owner() Owner {
+	return Owner{
+		ClientID:  st.ClientID,
+		ProjectID: st.ProjectID,
+		Email:     st.Email,
+	}
+}
+
+func (st *StoredToken) IsLegacy() bool {
+	return st.ClientID == ""
+}
+
+func (st *StoredToken) BelongsTo(clientID string) bool {
+	return st.ClientID != "" && st.ClientID == clientID
+}
+
 func (st *StoredToken) HasScopes(requiredScopes []string) bool {
 	if len(requiredScopes) == 0 {
 		return true
@@ -135,9 +168,14 @@ func (st *StoredToken) GetSummary() string {
 		status = "Expired"
 	}
 
-	return fmt.Sprintf("Token saved: %s | Status: %s | Scopes: %d | Expires: %s",
+	summary := fmt.Sprintf("Token saved: %s | Status: %s | Scopes: %d | Expires: %s",
 		st.SavedAt.Format("2006-01-02 15:04:05"),
 		status,
 		len(st.Scopes),
 		st.ExpiresAt.Format("2006-01-02 15:04:05"))
+
+	if st.Email != "" {
+		summary += " | Account: " + st.Email
+	}
+	return summary
 }
@@ -13,6 +13,10 @@ type Terminal struct {
 	paginationStyle   lipgloss.Style
 	helpStyle         lipgloss.Style
 	quitTextStyle     lipgloss.Style
+	noticeStyle       lipgloss.Style
+	restrictedStyle   lipgloss.Style
+	sensitiveStyle    lipgloss.Style
+	notice            string
 	model             *model
 }
 
@@ -22,6 +26,7 @@ type Item struct {
 	Title       string
 	Description string
 	Value       string
+	Sensitivity string
 	IsHeader    bool
 	Children    []Item
 }
@@ -49,5 +54,6 @@ type model struct {
 	currentService   string
 	serviceItems     []Item
 	scopeItems       []Item
+	sensitivity      map[string]string
 	breadcrumb       []string
 }
@@ -2,6 +2,7 @@ package terminal
 
 import (
 	"fmt"
+	"google-auth-wizard/googlescopes"
 	"io"
 	"strings"
 
@@ -19,6 +20,9 @@ func New(opts ...Option) *Terminal {
 		defaultPaginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
 		defaultHelpStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
 		defaultQuitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
+		defaultNoticeStyle       = lipgloss.NewStyle().MarginLeft(2).Foreground(lipgloss.Color("214"))
+		defaultRestrictedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		defaultSensitiveStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 	)
 
 	t := &Terminal{
@@ -29,6 +33,9 @@ func New(opts ...Option) *Terminal {
 		paginationStyle:   defaultPaginationStyle,
 		helpStyle:         defaultHelpStyle,
 		quitTextStyle:     defaultQuitTextStyle,
+		noticeStyle:       defaultNoticeStyle,
+		restrictedStyle:   defaultRestrictedStyle,
+		sensitiveStyle:    defaultSensitiveStyle,
 	}
 
 	for _, opt := range opts {
@@ -40,12 +47,14 @@ func New(opts ...Option) *Terminal {
 
 func (t *Terminal) Run(title string, items []Item) ([]string, error) {
 	serviceItems := make([]Item, 0)
-	serviceMap := make(map[string][]Item)
+	sensitivity := make(map[string]string)
 
 	for _, item := range items {
 		if item.IsHeader {
 			serviceItems = append(serviceItems, item)
-			serviceMap[item.Title] = item.Children
+			for _, child := range item.Children {
+				sensitivity[child.Value] = child.Sensitivity
+			}
 		}
 	}
 
@@ -62,6 +71,7 @@ func (t *Terminal) Run(title string, items []Item) ([]string, error) {
 		terminal:         t,
 		viewState:        ViewServices,
 		serviceItems:     serviceItems,
+		sensitivity:      sensitivity,
 		breadcrumb:       []string{title},
 		hasBeenValidated: false,
 	}
@@ -116,10 +126,19 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 			str += fmt.Sprintf(" (%d scopes)", len(i.Children))
 		}
 		if selected {
-			_, _ = fmt.Fprint(w, d.model.terminal.selectedItemStyle.Render("> "+str))
+			str = d.model.terminal.selectedItemStyle.Render("> " + str)
 		} else {
-			_, _ = fmt.Fprint(w, d.model.terminal.titleStyle.Render(str))
+			str = d.model.terminal.titleStyle.Render(str)
+		}
+
+		restricted, sensitive := countSensitivity(i.Children)
+		if restricted > 0 {
+			str += " " + d.model.terminal.restrictedStyle.Render(fmt.Sprintf("%d restricted", restricted))
+		}
+		if sensitive > 0 {
+			str += " " + d.model.terminal.sensitiveStyle.Render(fmt.Sprintf("%d sensitive", sensitive))
 		}
+		_, _ = fmt.Fprint(w, str)
 	} else {
 		var s strings.Builder
 		if selected {
@@ -146,18 +165,44 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 
 		s.WriteString(i.Title)
 
+		style := d.model.terminal.itemStyle
+		if selected {
+			style = d.model.terminal.selectedItemStyle
+		}
+		str := style.Render(s.String()) + d.badge(i.Sensitivity)
+
 		if len(i.Description) > 60 {
-			s.WriteString(fmt.Sprintf("\n      %s", i.Description[:60]+"..."))
+			str += "\n" + style.Render(fmt.Sprintf("      %s", i.Description[:60]+"..."))
 		} else if i.Description != "" {
-			s.WriteString(fmt.Sprintf("\n      %s", i.Description))
+			str += "\n" + style.Render(fmt.Sprintf("      %s", i.Description))
 		}
 
-		if selected {
-			_, _ = fmt.Fprint(w, d.model.terminal.selectedItemStyle.Render(s.String()))
-		} else {
-			_, _ = fmt.Fprint(w, d.model.terminal.itemStyle.Render(s.String()))
+		_, _ = fmt.Fprint(w, str)
+	}
+}
+
+func (d itemDelegate) badge(sensitivity string) string {
+	switch sensitivity {
+	case googlescopes.SENSITIVITY_RESTRICTED:
+		return " " + d.model.terminal.restrictedStyle.Render("[restricted]")
+	case googlescopes.SENSITIVITY_SENSITIVE:
+		return " " + d.model.terminal.sensitiveStyle.Render("[sensitive]")
+	default:
+		return ""
+	}
+}
+
+func countSensitivity(items []Item) (int, int) {
+	restricted, sensitive := 0, 0
+	for _, item := range items {
+		switch item.Sensitivity {
+		case googlescopes.SENSITIVITY_RESTRICTED:
+			restricted++
+		case googlescopes.SENSITIVITY_SENSITIVE:
+			sensitive++
 		}
 	}
+	return restricted, sensitive
 }
 func (m *model) Init() tea.Cmd {
 	return nil
@@ -244,24 +289,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.viewState = ViewConfirm
 					m.breadcrumb = append(m.breadcrumb, "Confirm Selection")
 
-					confirmItems := make([]list.Item, len(m.choice)+1)
-					for idx, choice := range m.choice {
-						confirmItems[idx] = Item{
-							Title:       choice,
-							Description: "Selected scope",
-							Value:       choice,
-							IsHeader:    false,
-						}
-					}
-					confirmItems[len(m.choice)] = Item{
-						Title:       "✓ Confirm Selection",
-						Description: "Press Enter to confirm",
-						Value:       "confirm",
-						IsHeader:    false,
-					}
-
-					listItems := make([]list.Item, len(confirmItems))
-					copy(listItems, confirmItems)
+					listItems := m.confirmItems()
 
 					m.list.SetItems(listItems)
 					m.list.Title = "Confirm Selection"
@@ -275,24 +303,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.viewState = ViewConfirm
 					m.breadcrumb = append(m.breadcrumb, "Confirm Selection")
 
-					confirmItems := make([]list.Item, len(m.choice)+1)
-					for idx, choice := range m.choice {
-						confirmItems[idx] = Item{
-							Title:       choice,
-							Description: "Selected scope",
-							Value:       choice,
-							IsHeader:    false,
-						}
-					}
-					confirmItems[len(m.choice)] = Item{
-						Title:       "✓ Confirm Selection",
-						Description: "Press Enter to confirm",
-						Value:       "confirm",
-						IsHeader:    false,
-					}
-
-					listItems := make([]list.Item, len(confirmItems))
-					copy(listItems, confirmItems)
+					listItems := m.confirmItems()
 					m.list.SetItems(listItems)
 					m.list.Title = "Confirm Selection"
 					m.list.ResetSelected()
@@ -362,12 +373,71 @@ func (m *model) View() string {
 		status = "Filtering... | Esc to cancel | Enter to apply"
 	}
 
+	header := m.terminal.titleStyle.Render(breadcrumbStr)
+	if m.terminal.notice != "" {
+		header += "\n" + m.terminal.noticeStyle.Render(m.terminal.notice)
+	}
+	if m.viewState == ViewConfirm {
+		if summary := m.sensitivitySummary(); summary != "" {
+			header += "\n" + summary
+		}
+	}
+
 	return fmt.Sprintf("\n%s\n\n%s\n\n%s\n",
-		m.terminal.titleStyle.Render(breadcrumbStr),
+		header,
 		m.list.View(),
 		status)
 }
 
+func (m *model) confirmItems() []list.Item {
+	listItems := make([]list.Item, len(m.choice)+1)
+	for idx, choice := range m.choice {
+		listItems[idx] = Item{
+			Title:       choice,
+			Description: "Selected scope",
+			Value:       choice,
+			Sensitivity: m.sensitivity[choice],
+			IsHeader:    false,
+		}
+	}
+	listItems[len(m.choice)] = Item{
+		Title:       "✓ Confirm Selection",
+		Description: "Press Enter to confirm",
+		Value:       "confirm",
+		IsHeader:    false,
+	}
+	return listItems
+}
+
+func (m *model) sensitivitySummary() string {
+	restricted, sensitive, unknown := 0, 0, 0
+	for _, choice := range m.choice {
+		switch m.sensitivity[choice] {
+		case googlescopes.SENSITIVITY_RESTRICTED:
+			restricted++
+		case googlescopes.SENSITIVITY_SENSITIVE:
+			sensitive++
+		case googlescopes.SENSITIVITY_UNKNOWN:
+			unknown++
+		}
+	}
+
+	var lines []string
+	switch {
+	case restricted > 0:
+		lines = append(lines, m.terminal.noticeStyle.Inherit(m.terminal.restrictedStyle).Render(fmt.Sprintf(
+			"⚠ %d restricted and %d sensitive scopes selected: publishing the app requires Google verification and a security assessment", restricted, sensitive)))
+	case sensitive > 0:
+		lines = append(lines, m.terminal.noticeStyle.Inherit(m.terminal.sensitiveStyle).Render(fmt.Sprintf(
+			"⚠ %d sensitive scopes selected: publishing the app requires Google verification", sensitive)))
+	}
+	if unknown > 0 {
+		lines = append(lines, m.terminal.noticeStyle.Render(fmt.Sprintf(
+			"%d unclassified scopes are missing from the sensitivity table and were not checked", unknown)))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (m *model) isSelected(value string) bool {
 	for _, choice := range m.choice {
 		if choice == value {
@@ -430,3 +500,27 @@ func WithQuitTextStyle(quitTextStyle lipgloss.Style) Option {
 		e.quitTextStyle = quitTextStyle
 	}
 }
+
+func WithNoticeStyle(noticeStyle lipgloss.Style) Option {
+	return func(e *Terminal) {
+		e.noticeStyle = noticeStyle
+	}
+}
+
+func WithRestrictedStyle(restrictedStyle lipgloss.Style) Option {
+	return func(e *Terminal) {
+		e.restrictedStyle = restrictedStyle
+	}
+}
+
+func WithSensitiveStyle(sensitiveStyle lipgloss.Style) Option {
+	return func(e *Terminal) {
+		e.sensitiveStyle = sensitiveStyle
+	}
+}
+
+func WithNotice(notice string) Option {
+	return func(e *Terminal) {
+		e.notice = notice
+	}
+}
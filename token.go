@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"google-auth-wizard/config"
+	"google-auth-wizard/storage"
+	"google-auth-wizard/utils"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	TOKEN_FORMAT_RAW    = "raw"
+	TOKEN_FORMAT_JSON   = "json"
+	TOKEN_FORMAT_ENV    = "env"
+	TOKEN_FORMAT_HEADER = "header"
+	TOKEN_FORMAT_DOTENV = "dotenv"
+
+	DEFAULT_TOKEN_ENV_VAR = "GOOGLE_OAUTH_ACCESS_TOKEN"
+)
+
+var envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type tokenOutput struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+	Scopes      []string  `json:"scopes"`
+}
+
+func runToken(args []string) error {
+	flagSet := flag.NewFlagSet("token", flag.ContinueOnError)
+	filename := flagSet.String("file", "", "Path to the client secret JSON file, needed to refresh an expired token")
+	flagSet.StringVar(filename, "f", "", "Path to the client secret JSON file (shortcut)")
+	profile := flagSet.String("profile", "", "Token profile to read (defaults to the current profile)")
+	format := flagSet.String("format", TOKEN_FORMAT_RAW, "Output format: raw, json, env, header or dotenv")
+	variable := flagSet.String("var", DEFAULT_TOKEN_ENV_VAR, "Variable name used by the env and dotenv formats")
+	output := flagSet.String("output", "", "Write to this file instead of standard output")
+	flagSet.StringVar(output, "o", "", "Write to this file instead of standard output (shortcut)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if !isValidTokenFormat(*format) {
+		return fmt.Errorf("invalid format %q: must be %s, %s, %s, %s or %s", *format,
+			TOKEN_FORMAT_RAW, TOKEN_FORMAT_JSON, TOKEN_FORMAT_ENV, TOKEN_FORMAT_HEADER, TOKEN_FORMAT_DOTENV)
+	}
+
+	if !envVarNamePattern.MatchString(*variable) {
+		return fmt.Errorf("invalid variable name %q", *variable)
+	}
+
+	cfg := config.LoadConfigWithDefaults("config.yaml")
+	tokenStorage, name, err := openProfile(cfg, *profile)
+	if err != nil {
+		return err
+	}
+
+	var credentials []byte
+	if *filename != "" {
+		credentials = utils.ReadCredentials(*filename)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	storedToken, err := newProfileTokenSource(ctx, tokenStorage, name, credentials).StoredToken()
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return writeToken(os.Stdout, *format, *variable, storedToken)
+	}
+
+	var buffer strings.Builder
+	if err := writeToken(&buffer, *format, *variable, storedToken); err != nil {
+		return err
+	}
+	return storage.WriteFileAtomic(*output, []byte(buffer.String()), 0600)
+}
+
+func isValidTokenFormat(format string) bool {
+	switch format {
+	case TOKEN_FORMAT_RAW, TOKEN_FORMAT_JSON, TOKEN_FORMAT_ENV, TOKEN_FORMAT_HEADER, TOKEN_FORMAT_DOTENV:
+		return true
+	default:
+		return false
+	}
+}
+
+func writeToken(w io.Writer, format, variable string, storedToken *storage.StoredToken) error {
+	token := storedToken.Token
+
+	var err error
+	switch format {
+	case TOKEN_FORMAT_RAW:
+		_, err = fmt.Fprintln(w, token.AccessToken)
+	case TOKEN_FORMAT_JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(tokenOutput{
+			AccessToken: token.AccessToken,
+			TokenType:   token.Type(),
+			Expiry:      token.Expiry,
+			Scopes:      storedToken.Scopes,
+		})
+	case TOKEN_FORMAT_ENV:
+		_, err = fmt.Fprintf(w, "export %s='%s'\n", variable, token.AccessToken)
+	case TOKEN_FORMAT_HEADER:
+		_, err = fmt.Fprintf(w, "Authorization: %s %s\n", token.Type(), token.AccessToken)
+	case TOKEN_FORMAT_DOTENV:
+		_, err = fmt.Fprintf(w, "%s=%s\n", variable, token.AccessToken)
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+	return err
+}
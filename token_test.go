@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"google-auth-wizard/storage"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWriteToken(t *testing.T) {
+	expiry := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	storedToken := &storage.StoredToken{
+		Token:  &oauth2.Token{AccessToken: "ya29.access", TokenType: "Bearer", Expiry: expiry},
+		Scopes: []string{"https://www.googleapis.com/auth/drive"},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		variable string
+		want     string
+	}{
+		{"raw", TOKEN_FORMAT_RAW, DEFAULT_TOKEN_ENV_VAR, "ya29.access\n"},
+		{"env", TOKEN_FORMAT_ENV, DEFAULT_TOKEN_ENV_VAR, "export GOOGLE_OAUTH_ACCESS_TOKEN='ya29.access'\n"},
+		{"env with custom variable", TOKEN_FORMAT_ENV, "ACCESS_TOKEN", "export ACCESS_TOKEN='ya29.access'\n"},
+		{"header", TOKEN_FORMAT_HEADER, DEFAULT_TOKEN_ENV_VAR, "Authorization: Bearer ya29.access\n"},
+		{"dotenv", TOKEN_FORMAT_DOTENV, DEFAULT_TOKEN_ENV_VAR, "GOOGLE_OAUTH_ACCESS_TOKEN=ya29.access\n"},
+		{"dotenv with custom variable", TOKEN_FORMAT_DOTENV, "ACCESS_TOKEN", "ACCESS_TOKEN=ya29.access\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := writeToken(&buffer, tt.format, tt.variable, storedToken); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if buffer.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, buffer.String())
+			}
+		})
+	}
+}
+
+func TestWriteToken_JSON(t *testing.T) {
+	expiry := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	storedToken := &storage.StoredToken{
+		Token:  &oauth2.Token{AccessToken: "ya29.access", Expiry: expiry},
+		Scopes: []string{"https://www.googleapis.com/auth/drive"},
+	}
+
+	var buffer bytes.Buffer
+	if err := writeToken(&buffer, TOKEN_FORMAT_JSON, DEFAULT_TOKEN_ENV_VAR, storedToken); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var output tokenOutput
+	if err := json.Unmarshal(buffer.Bytes(), &output); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if output.AccessToken != "ya29.access" || output.TokenType != "Bearer" {
+		t.Errorf("Unexpected token output: %+v", output)
+	}
+	if !output.Expiry.Equal(expiry) {
+		t.Errorf("Expected expiry %v, got %v", expiry, output.Expiry)
+	}
+	if len(output.Scopes) != 1 || output.Scopes[0] != "https://www.googleapis.com/auth/drive" {
+		t.Errorf("Expected the stored scopes, got %v", output.Scopes)
+	}
+}
+
+func TestWriteToken_InvalidFormat(t *testing.T) {
+	storedToken := &storage.StoredToken{Token: &oauth2.Token{AccessToken: "ya29.access"}}
+
+	var buffer bytes.Buffer
+	if err := writeToken(&buffer, "yaml", DEFAULT_TOKEN_ENV_VAR, storedToken); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("Expected nothing written, got %q", buffer.String())
+	}
+}
+
+func TestEnvVarNamePattern(t *testing.T) {
+	for _, name := range []string{"GOOGLE_OAUTH_ACCESS_TOKEN", "_token", "Token2"} {
+		if !envVarNamePattern.MatchString(name) {
+			t.Errorf("Expected %q to be a valid variable name", name)
+		}
+	}
+	for _, name := range []string{"", "2TOKEN", "MY-TOKEN", "TOKEN=x", "A B"} {
+		if envVarNamePattern.MatchString(name) {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
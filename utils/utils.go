@@ -1,15 +1,23 @@
 package utils
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+const (
+	LOOPBACK_IPV4 = "127.0.0.1"
+	LOOPBACK_IPV6 = "::1"
 )
 
 func IsRunningWithGoRun() bool {
@@ -19,25 +27,58 @@ func IsRunningWithGoRun() bool {
 		strings.Contains(execPath, "go-build")
 }
 
-func ParseFlags() string {
-	var filename string
-	var forceNew bool
-	var clearTokens bool
+type Flags struct {
+	Filename      string
+	Flow          string
+	Profile       string
+	Scopes        []string
+	ScopesFile    string
+	StrictScopes  bool
+	RefreshScopes bool
+	ClearTokens   bool
+}
+
+func (f *Flags) HasScopes() bool {
+	return len(f.Scopes) > 0 || f.ScopesFile != ""
+}
 
-	flag.StringVar(&filename, "file", "", "Path to JSON file")
-	flag.StringVar(&filename, "f", "", "Path to JSON file (shortcut)")
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func ParseFlags() *Flags {
+	flags := &Flags{}
+	var forceNew bool
+	var scopes stringListFlag
+
+	flag.StringVar(&flags.Filename, "file", "", "Path to JSON file")
+	flag.StringVar(&flags.Filename, "f", "", "Path to JSON file (shortcut)")
+	flag.StringVar(&flags.Flow, "flow", "", "Authorization flow: loopback, device or manual (overrides config)")
+	flag.StringVar(&flags.Profile, "profile", "", "Token profile to use (defaults to the current profile)")
+	flag.Var(&scopes, "scopes", "Comma-separated scopes to request without the selection interface (repeatable)")
+	flag.StringVar(&flags.ScopesFile, "scopes-file", "", "File listing the scopes to request, one per line (# starts a comment)")
+	flag.BoolVar(&flags.StrictScopes, "strict-scopes", false, "Fail instead of warning when a requested scope is unknown")
+	flag.BoolVar(&flags.RefreshScopes, "refresh-scopes", false, "Fetch the scope catalog again even if the cached copy is still fresh")
 	flag.BoolVar(&forceNew, "force-new", false, "Force getting a new token (ignore saved tokens)")
 	flag.BoolVar(&forceNew, "n", false, "Force getting a new token (shortcut)")
-	flag.BoolVar(&clearTokens, "clear-tokens", false, "Clear all saved tokens and exit")
-	flag.BoolVar(&clearTokens, "c", false, "Clear all saved tokens and exit (shortcut)")
+	flag.BoolVar(&flags.ClearTokens, "clear-tokens", false, "Clear the profile's saved token locally and exit (use the revoke command to also revoke it at Google)")
+	flag.BoolVar(&flags.ClearTokens, "c", false, "Clear the profile's saved token locally and exit (shortcut)")
 	flag.Parse()
 
-	if clearTokens {
-		clearSavedTokens()
-		os.Exit(0)
+	if flags.ClearTokens {
+		return flags
 	}
 
-	if filename == "" {
+	flags.Scopes = ParseScopeList(scopes...)
+
+	if flags.Filename == "" {
 		printUsage()
 		os.Exit(1)
 	}
@@ -46,24 +87,48 @@ func ParseFlags() string {
 		_ = os.Setenv("GOOGLE_AUTH_WIZARD_FORCE_NEW", "true")
 	}
 
-	return filename
+	return flags
 }
 
 func printUsage() {
 	fmt.Println("Error: No file specified")
 	fmt.Println("\nUsage:")
-	fmt.Printf("  %s -file <client_secret_[id].apps.googleusercontent.com.json>\n", Ternary(IsRunningWithGoRun(), "go run main.go", "./google-auth-wizard"))
-	fmt.Printf("  %s -f <client_secret_[id].apps.googleusercontent.com.json>\n", Ternary(IsRunningWithGoRun(), "go run main.go", "./google-auth-wizard"))
+	fmt.Printf("  %s -file <client_secret_[id].apps.googleusercontent.com.json>\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f <client_secret_[id].apps.googleusercontent.com.json>\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
 	fmt.Println("\nOptions:")
 	flag.PrintDefaults()
 	fmt.Println("\nExamples:")
-	fmt.Printf("  %s -f credentials.json                 # Use saved token if available\n", Ternary(IsRunningWithGoRun(), "go run main.go", "./google-auth-wizard"))
-	fmt.Printf("  %s -f credentials.json -n              # Force new token\n", Ternary(IsRunningWithGoRun(), "go run main.go", "./google-auth-wizard"))
-	fmt.Printf("  %s -c                                  # Clear saved tokens\n", Ternary(IsRunningWithGoRun(), "go run main.go", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json                 # Use saved token if available\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -n              # Force new token\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -flow device    # Authorize from another device (SSH, headless)\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -flow manual    # Paste the redirect URL back from another machine\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -scopes https://www.googleapis.com/auth/drive.readonly,openid\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -scopes-file scopes.txt -strict-scopes\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -profile work   # Use (or create) the \"work\" token profile\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -f credentials.json -refresh-scopes # Ignore the cached scope catalog\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s -c                                  # Clear saved tokens\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s profiles list                       # List token profiles\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s profiles use work                   # Switch the current profile\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s profiles delete work                # Delete a profile's saved token\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s revoke                              # Revoke the saved token at Google, then clear it\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s export-adc -f credentials.json      # Write gcloud Application Default Credentials\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s token -f credentials.json           # Print the access token (refreshed if needed)\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s token -format header                # Print an Authorization header line\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s exec -profile work -- curl ...      # Run a command with GOOGLE_OAUTH_ACCESS_TOKEN set\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s serve-metadata -f credentials.json  # Emulate the GCE metadata server (GCE_METADATA_HOST)\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s proxy -f credentials.json           # Forward local requests to Google APIs with the token\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s scopes diff                         # Compare the cached scope catalog with a fresh fetch\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
+	fmt.Printf("  %s scopes diff old.json new.json       # Compare two catalogs written by ToJSON\n", Ternary(IsRunningWithGoRun(), "go run .", "./google-auth-wizard"))
 	fmt.Println("\nEnvironment Variables:")
 	fmt.Println("  GOOGLE_AUTH_WIZARD_DEBUG=true         # Enable debug logging")
 	fmt.Println("  GOOGLE_AUTH_WIZARD_VERBOSE=true       # Enable verbose logging")
 	fmt.Println("  GOOGLE_AUTH_WIZARD_SILENT=true        # Silent mode")
+	fmt.Println("  GOOGLE_AUTH_WIZARD_FLOW=device        # Authorization flow (loopback, device or manual)")
+	fmt.Println("  GOOGLE_AUTH_WIZARD_REVOKE_URL=<url>   # Token revocation endpoint")
+	fmt.Println("  GOOGLE_AUTH_WIZARD_STORAGE_BACKEND=encrypted  # Token storage backend (file or encrypted)")
+	fmt.Println("  GOOGLE_AUTH_WIZARD_PASSPHRASE=<secret>        # Passphrase for the encrypted token storage")
+	fmt.Println("  GOOGLE_AUTH_WIZARD_EXEC_TOKEN_VARS=A,B        # Variables receiving the token in the exec command")
+	fmt.Println("  GOOGLE_AUTH_WIZARD_PROXY_UPSTREAM=<url>       # Upstream of the proxy command")
 }
 
 func ReadCredentials(filename string) []byte {
@@ -74,25 +139,84 @@ func ReadCredentials(filename string) []byte {
 	return credentials
 }
 
-func FindAvailablePort(defaultPort int, maxPortTries int) (int, error) {
-	for port := defaultPort; port < defaultPort+maxPortTries; port++ {
-		if isPortAvailable(port) {
-			return port, nil
+func ParseScopeList(values ...string) []string {
+	seen := make(map[string]bool)
+	var scopes []string
+
+	for _, value := range values {
+		for _, scope := range strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
 		}
 	}
-	return 0, fmt.Errorf("no available port found in range %d-%d", defaultPort, defaultPort+maxPortTries-1)
+
+	return scopes
 }
 
-func isPortAvailable(port int) bool {
-	addr := fmt.Sprintf(":%d", port)
-	listener, err := net.Listen("tcp", addr)
+func ReadScopesFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("unable to read scopes file: %w", err)
 	}
 	defer func() {
-		_ = listener.Close()
+		_ = file.Close()
 	}()
-	return true
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read scopes file: %w", err)
+	}
+
+	return ParseScopeList(lines...), nil
+}
+
+func ReadPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("no terminal available to read the passphrase")
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("unable to read passphrase: %w", err)
+	}
+
+	return string(passphrase), nil
+}
+
+func ListenLoopback(defaultPort int, maxPortTries int) (net.Listener, error) {
+	if defaultPort == 0 {
+		return net.Listen("tcp", net.JoinHostPort(LOOPBACK_IPV4, "0"))
+	}
+
+	for port := defaultPort; port < defaultPort+maxPortTries; port++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(LOOPBACK_IPV4, strconv.Itoa(port)))
+		if err == nil {
+			return listener, nil
+		}
+	}
+	return nil, fmt.Errorf("no available port found in range %d-%d", defaultPort, defaultPort+maxPortTries-1)
+}
+
+func ListenLoopbackIPv6(port int) (net.Listener, error) {
+	return net.Listen("tcp", net.JoinHostPort(LOOPBACK_IPV6, strconv.Itoa(port)))
+}
+
+func ListenerPort(listener net.Listener) int {
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
 }
 
 func OpenBrowser(url string) error {
@@ -119,25 +243,3 @@ func Ternary[T any](condition bool, trueVal, falseVal T) T {
 	}
 	return falseVal
 }
-
-func clearSavedTokens() {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Printf("Error getting home directory: %v\n", err)
-		return
-	}
-
-	tokenPath := filepath.Join(homeDir, ".google-auth-wizard", "token.json")
-
-	if _, err := os.Stat(tokenPath); os.IsNotExist(err) {
-		fmt.Println("No saved tokens found.")
-		return
-	}
-
-	if err := os.Remove(tokenPath); err != nil {
-		fmt.Printf("Error removing token file: %v\n", err)
-		return
-	}
-
-	fmt.Println("Saved tokens cleared successfully.")
-}
@@ -4,34 +4,57 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
-func TestFindAvailablePort_Success(t *testing.T) {
-	port, err := FindAvailablePort(8080, 10)
+func TestListenLoopback_Success(t *testing.T) {
+	listener, err := ListenLoopback(8080, 10)
 	if err != nil {
-		t.Errorf("Expected to find available port, got error: %v", err)
+		t.Fatalf("Expected to acquire a listener, got error: %v", err)
 	}
+	defer func() {
+		_ = listener.Close()
+	}()
 
+	port := ListenerPort(listener)
 	if port < 8080 || port >= 8090 {
 		t.Errorf("Expected port in range 8080-8089, got %d", port)
 	}
 
-	listener, err := net.Listen("tcp", ":"+fmt.Sprintf("%d", port))
+	addr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok || !addr.IP.IsLoopback() {
+		t.Errorf("Expected listener bound to loopback, got %v", listener.Addr())
+	}
+
+	if other, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+		_ = other.Close()
+		t.Errorf("Port %d should still be held by the returned listener", port)
+	}
+}
+
+func TestListenLoopback_OSAssignedPort(t *testing.T) {
+	listener, err := ListenLoopback(0, 1)
 	if err != nil {
-		t.Errorf("Port %d should be available but got error: %v", port, err)
-	} else {
+		t.Fatalf("Expected to acquire a listener, got error: %v", err)
+	}
+	defer func() {
 		_ = listener.Close()
+	}()
+
+	if ListenerPort(listener) == 0 {
+		t.Error("Expected the OS to assign a non-zero port")
 	}
 }
 
-func TestFindAvailablePort_NoPortsAvailable(t *testing.T) {
+func TestListenLoopback_NoPortsAvailable(t *testing.T) {
 	var listeners []net.Listener
 	basePort := 9000
 	maxPorts := 3
 
 	for i := 0; i < maxPorts; i++ {
-		addr := fmt.Sprintf(":%d", basePort+i)
+		addr := fmt.Sprintf("127.0.0.1:%d", basePort+i)
 		listener, err := net.Listen("tcp", addr)
 		if err == nil {
 			listeners = append(listeners, listener)
@@ -44,12 +67,59 @@ func TestFindAvailablePort_NoPortsAvailable(t *testing.T) {
 		}
 	}()
 
-	_, err := FindAvailablePort(basePort, maxPorts)
+	listener, err := ListenLoopback(basePort, maxPorts)
 	if err == nil {
+		_ = listener.Close()
 		t.Error("Expected error when no ports are available, got nil")
 	}
 }
 
+func TestParseScopeList(t *testing.T) {
+	scopes := ParseScopeList(
+		"https://www.googleapis.com/auth/drive, openid",
+		"email,,openid",
+		" https://www.googleapis.com/auth/gmail.readonly ",
+	)
+
+	expected := []string{
+		"https://www.googleapis.com/auth/drive",
+		"openid",
+		"email",
+		"https://www.googleapis.com/auth/gmail.readonly",
+	}
+	if !reflect.DeepEqual(scopes, expected) {
+		t.Errorf("Expected %v, got %v", expected, scopes)
+	}
+
+	if scopes := ParseScopeList(); len(scopes) != 0 {
+		t.Errorf("Expected no scopes, got %v", scopes)
+	}
+}
+
+func TestReadScopesFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "scopes.txt")
+	content := "# Drive access\nhttps://www.googleapis.com/auth/drive.readonly\n\nopenid, email # identity\nhttps://www.googleapis.com/auth/drive.readonly\n"
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write scopes file: %v", err)
+	}
+
+	scopes, err := ReadScopesFile(filename)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"https://www.googleapis.com/auth/drive.readonly", "openid", "email"}
+	if !reflect.DeepEqual(scopes, expected) {
+		t.Errorf("Expected %v, got %v", expected, scopes)
+	}
+}
+
+func TestReadScopesFile_NotFound(t *testing.T) {
+	if _, err := ReadScopesFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing scopes file, got nil")
+	}
+}
+
 func TestTernary(t *testing.T) {
 	result := Ternary(true, "yes", "no")
 	if result != "yes" {